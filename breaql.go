@@ -1,72 +1,219 @@
 package breaql
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/samber/lo"
 )
 
 type BreakingChanges struct {
-	Tables    TableChanges    `json:"tables"`
-	Indexes   IndexChanges    `json:"indexes"`
-	Schemas   SchemaChanges   `json:"schemas"`
-	Databases DatabaseChanges `json:"databases"`
+	Findings   []Finding        `json:"findings"`   // in the order of detection
+	Suppressed []Finding        `json:"suppressed"` // findings accepted by inline comments, not in the buckets
+	Tables     TableChanges     `json:"tables"`
+	Views      ViewChanges      `json:"views"`
+	Indexes    IndexChanges     `json:"indexes"`
+	Sequences  SequenceChanges  `json:"sequences"`
+	Types      TypeChanges      `json:"types"`
+	Triggers   TriggerChanges   `json:"triggers"`
+	Policies   PolicyChanges    `json:"policies"`
+	Routines   RoutineChanges   `json:"routines"`
+	Extensions ExtensionChanges `json:"extensions"`
+	Schemas    SchemaChanges    `json:"schemas"`
+	Databases  DatabaseChanges  `json:"databases"`
+
+	// Columns and Constraints break down the changes to tables by the affected columns and constraints.
+	// The statements in them are also listed under Tables.
+	Columns     ColumnChanges     `json:"columns"`
+	Constraints ConstraintChanges `json:"constraints"`
 }
 
 func NewBreakingChanges() BreakingChanges {
 	return BreakingChanges{
-		Tables:    make(TableChanges),
-		Indexes:   make(IndexChanges),
-		Schemas:   make(SchemaChanges),
-		Databases: make(DatabaseChanges),
+		Tables:      make(TableChanges),
+		Views:       make(ViewChanges),
+		Indexes:     make(IndexChanges),
+		Sequences:   make(SequenceChanges),
+		Types:       make(TypeChanges),
+		Triggers:    make(TriggerChanges),
+		Policies:    make(PolicyChanges),
+		Routines:    make(RoutineChanges),
+		Extensions:  make(ExtensionChanges),
+		Schemas:     make(SchemaChanges),
+		Databases:   make(DatabaseChanges),
+		Columns:     make(ColumnChanges),
+		Constraints: make(ConstraintChanges),
+	}
+}
+
+// add records the finding and the statement under the bucket of the affected object,
+// or among the suppressed findings.
+func (bc *BreakingChanges) add(f Finding) {
+	// A statement naming an object twice (e.g. DROP TABLE a, a) reports it once.
+	duplicate := func(prev Finding) bool {
+		return prev.Position == f.Position && prev.RuleID == f.RuleID && prev.Object == f.Object &&
+			prev.Column == f.Column && prev.Constraint == f.Constraint
+	}
+	if slices.ContainsFunc(bc.Findings, duplicate) || slices.ContainsFunc(bc.Suppressed, duplicate) {
+		return
+	}
+
+	if f.Suppression != nil {
+		bc.Suppressed = append(bc.Suppressed, f)
+		return
+	}
+
+	// A statement may yield several findings for the same object (e.g. dropping two columns at once),
+	// but the buckets list each statement only once.
+	recorded := func(same func(prev Finding) bool) bool {
+		return slices.ContainsFunc(bc.Findings, func(prev Finding) bool {
+			return prev.Position == f.Position && prev.Statement == f.Statement && prev.Category == f.Category &&
+				prev.ObjectKind == f.ObjectKind && prev.Object == f.Object && same(prev)
+		})
+	}
+	// Operational risks are not breaking changes of the objects.
+	if f.Category == CategoryOperational {
+		bc.Findings = append(bc.Findings, f)
+		return
+	}
+	newObject := !recorded(func(Finding) bool { return true })
+	newColumn := f.Column != "" && !recorded(func(prev Finding) bool { return prev.Column == f.Column })
+	newConstraint := f.Constraint != "" && !recorded(func(prev Finding) bool { return prev.Constraint == f.Constraint })
+	bc.Findings = append(bc.Findings, f)
+
+	if bucket := bc.bucket(f.ObjectKind); bucket != nil && newObject {
+		bucket[f.Object] = append(bucket[f.Object], f.Statement)
+	}
+	if newColumn {
+		key := f.Object + "." + f.Column
+		bc.Columns[key] = append(bc.Columns[key], f.Statement)
+	}
+	if newConstraint {
+		key := f.Object + "." + f.Constraint
+		bc.Constraints[key] = append(bc.Constraints[key], f.Statement)
+	}
+}
+
+// Filter returns the changes made up of the findings for which keep returns true.
+// The buckets are rebuilt from the findings, so entries without a finding are dropped.
+func (bc BreakingChanges) Filter(keep func(Finding) bool) BreakingChanges {
+	filtered := NewBreakingChanges()
+	for _, f := range slices.Concat(bc.Findings, bc.Suppressed) {
+		if keep(f) {
+			filtered.add(f)
+		}
+	}
+	return filtered
+}
+
+// bucket returns the changes of the given kind of objects.
+func (bc BreakingChanges) bucket(kind ObjectKind) map[string][]string {
+	switch kind {
+	case ObjectKindTable:
+		return bc.Tables
+	case ObjectKindView:
+		return bc.Views
+	case ObjectKindIndex:
+		return bc.Indexes
+	case ObjectKindSequence:
+		return bc.Sequences
+	case ObjectKindType:
+		return bc.Types
+	case ObjectKindTrigger:
+		return bc.Triggers
+	case ObjectKindPolicy:
+		return bc.Policies
+	case ObjectKindRoutine:
+		return bc.Routines
+	case ObjectKindExtension:
+		return bc.Extensions
+	case ObjectKindSchema:
+		return bc.Schemas
+	case ObjectKindDatabase:
+		return bc.Databases
 	}
+	return nil
+}
+
+// Statements returns the breaking statements for the given object.
+func (bc BreakingChanges) Statements(kind ObjectKind, name string) []string {
+	return bc.bucket(kind)[name]
 }
 
 // Exist return if any changes exist.
 func (bc BreakingChanges) Exist() bool {
-	return bc.Tables.Exist() || bc.Schemas.Exist() || bc.Databases.Exist() || bc.Indexes.Exist()
+	return slices.ContainsFunc(objectKinds, func(kind ObjectKind) bool { return len(bc.bucket(kind)) > 0 })
+}
+
+// OperationalRisks returns the findings of the operational category, which are not listed under the objects.
+func (bc BreakingChanges) OperationalRisks() []Finding {
+	return lo.Filter(bc.Findings, func(f Finding, _ int) bool { return f.Category == CategoryOperational })
 }
 
 // FormatSQL returns the breaking changes in SQL format.
+// Objects are listed in the order of their first finding; see Sorted for other orders.
 func (bc BreakingChanges) FormatSQL() string {
 	builder := strings.Builder{}
-	for _, table := range bc.Tables.Tables() {
-		builder.WriteString("-- Table: " + table + "\n")
-		for _, stmt := range bc.Tables.Statements(table) {
-			builder.WriteString("        " + stmt + "\n")
+	for _, kind := range objectKinds {
+		for _, name := range bc.Objects(kind) {
+			builder.WriteString("-- " + kind.label() + ": " + name + "\n")
+			for _, stmt := range bc.Statements(kind, name) {
+				builder.WriteString("        " + stmt + "\n")
+			}
 		}
 	}
-	for _, index := range bc.Indexes.Indexes() {
-		builder.WriteString("-- Index: " + index + "\n")
-		for _, stmt := range bc.Indexes.Statements(index) {
-			builder.WriteString("        " + stmt + "\n")
-		}
+
+	return builder.String()
+}
+
+// Order is the order in which findings and objects are reported.
+type Order int
+
+const (
+	// OrderSource reports findings in the order of their statements in the input.
+	OrderSource Order = iota
+	// OrderName reports findings sorted by the name of the affected object.
+	OrderName
+)
+
+// Sorted returns a copy of the changes whose findings are arranged in the given order.
+// Findings on the same object keep their source order.
+func (bc BreakingChanges) Sorted(order Order) BreakingChanges {
+	bc.Findings = slices.Clone(bc.Findings)
+	bc.Suppressed = slices.Clone(bc.Suppressed)
+	if order == OrderName {
+		byObject := func(a, b Finding) int { return strings.Compare(a.Object, b.Object) }
+		slices.SortStableFunc(bc.Findings, byObject)
+		slices.SortStableFunc(bc.Suppressed, byObject)
 	}
-	for _, schema := range bc.Schemas.Schemas() {
-		builder.WriteString("-- Schema: " + schema + "\n")
-		for _, stmt := range bc.Schemas.Statements(schema) {
-			builder.WriteString("        " + stmt + "\n")
+	return bc
+}
+
+// Objects returns the names of the affected objects of the given kind in the order of their first finding.
+// Objects that have no finding (e.g. added to the buckets directly) follow in lexical order.
+// Operational risks are not listed under the objects, so the objects only affected by them are not returned.
+func (bc BreakingChanges) Objects(kind ObjectKind) []string {
+	var names []string
+	for _, f := range bc.Findings {
+		if f.ObjectKind == kind && f.Category != CategoryOperational && !slices.Contains(names, f.Object) {
+			names = append(names, f.Object)
 		}
 	}
-	for _, database := range bc.Databases.Databases() {
-		builder.WriteString("-- Database: " + database + "\n")
-		for _, stmt := range bc.Databases.Statements(database) {
-			builder.WriteString("        " + stmt + "\n")
+
+	for _, name := range sortedKeys(bc.bucket(kind)) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
 		}
 	}
-
-	return builder.String()
+	return names
 }
 
 type TableChanges map[string][]string
 
-func (tc TableChanges) add(table string, statements ...string) {
-	tc[table] = append(tc[table], statements...)
-}
-
-// Tables returns the affected table names.
+// Tables returns the affected table names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
 func (tc TableChanges) Tables() []string {
-	return lo.Keys(tc)
+	return sortedKeys(tc)
 }
 
 // Statements returns the breaking statements for the given table.
@@ -79,14 +226,30 @@ func (tc TableChanges) Exist() bool {
 	return len(tc) > 0
 }
 
-type IndexChanges map[string][]string
+type ViewChanges map[string][]string
 
-func (ic IndexChanges) add(index string, statements ...string) {
-	ic[index] = append(ic[index], statements...)
+// Views returns the affected view names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
+func (vc ViewChanges) Views() []string {
+	return sortedKeys(vc)
 }
 
+// Statements returns the breaking statements for the given view.
+func (vc ViewChanges) Statements(view string) []string {
+	return vc[view]
+}
+
+// Exist return if any changes exist.
+func (vc ViewChanges) Exist() bool {
+	return len(vc) > 0
+}
+
+type IndexChanges map[string][]string
+
+// Indexes returns the affected index names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
 func (ic IndexChanges) Indexes() []string {
-	return lo.Keys(ic)
+	return sortedKeys(ic)
 }
 
 // Statements returns the breaking statements for the given index.
@@ -99,15 +262,125 @@ func (ic IndexChanges) Exist() bool {
 	return len(ic) > 0
 }
 
-type SchemaChanges map[string][]string
+type SequenceChanges map[string][]string
+
+// Sequences returns the affected sequence names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
+func (sc SequenceChanges) Sequences() []string {
+	return sortedKeys(sc)
+}
+
+// Statements returns the breaking statements for the given sequence.
+func (sc SequenceChanges) Statements(sequence string) []string {
+	return sc[sequence]
+}
 
-func (sc SchemaChanges) add(schema string, statements ...string) {
-	sc[schema] = append(sc[schema], statements...)
+// Exist return if any changes exist.
+func (sc SequenceChanges) Exist() bool {
+	return len(sc) > 0
 }
 
-// Schemas returns the affected schema names.
+// TypeChanges holds the breaking statements by the affected types and domains.
+type TypeChanges map[string][]string
+
+// Types returns the affected type names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
+func (tc TypeChanges) Types() []string {
+	return sortedKeys(tc)
+}
+
+// Statements returns the breaking statements for the given type.
+func (tc TypeChanges) Statements(typ string) []string {
+	return tc[typ]
+}
+
+// Exist return if any changes exist.
+func (tc TypeChanges) Exist() bool {
+	return len(tc) > 0
+}
+
+// TriggerChanges holds the breaking statements by the affected triggers, which are qualified by their tables in PostgreSQL.
+type TriggerChanges map[string][]string
+
+// Triggers returns the affected trigger names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
+func (tc TriggerChanges) Triggers() []string {
+	return sortedKeys(tc)
+}
+
+// Statements returns the breaking statements for the given trigger.
+func (tc TriggerChanges) Statements(trigger string) []string {
+	return tc[trigger]
+}
+
+// Exist return if any changes exist.
+func (tc TriggerChanges) Exist() bool {
+	return len(tc) > 0
+}
+
+// PolicyChanges holds the breaking statements by the affected row-level security policies, qualified by their tables.
+type PolicyChanges map[string][]string
+
+// Policies returns the affected policy names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
+func (pc PolicyChanges) Policies() []string {
+	return sortedKeys(pc)
+}
+
+// Statements returns the breaking statements for the given policy.
+func (pc PolicyChanges) Statements(policy string) []string {
+	return pc[policy]
+}
+
+// Exist return if any changes exist.
+func (pc PolicyChanges) Exist() bool {
+	return len(pc) > 0
+}
+
+// RoutineChanges holds the breaking statements by the affected stored procedures, functions and events.
+// The names of PostgreSQL functions and procedures are followed by their argument types, e.g. "public.add(int4, int4)".
+type RoutineChanges map[string][]string
+
+// Routines returns the affected routine names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
+func (rc RoutineChanges) Routines() []string {
+	return sortedKeys(rc)
+}
+
+// Statements returns the breaking statements for the given routine.
+func (rc RoutineChanges) Statements(routine string) []string {
+	return rc[routine]
+}
+
+// Exist return if any changes exist.
+func (rc RoutineChanges) Exist() bool {
+	return len(rc) > 0
+}
+
+type ExtensionChanges map[string][]string
+
+// Extensions returns the affected extension names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
+func (ec ExtensionChanges) Extensions() []string {
+	return sortedKeys(ec)
+}
+
+// Statements returns the breaking statements for the given extension.
+func (ec ExtensionChanges) Statements(extension string) []string {
+	return ec[extension]
+}
+
+// Exist return if any changes exist.
+func (ec ExtensionChanges) Exist() bool {
+	return len(ec) > 0
+}
+
+type SchemaChanges map[string][]string
+
+// Schemas returns the affected schema names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
 func (sc SchemaChanges) Schemas() []string {
-	return lo.Keys(sc)
+	return sortedKeys(sc)
 }
 
 // Statements returns the breaking statements for the given schema.
@@ -120,15 +393,51 @@ func (sc SchemaChanges) Exist() bool {
 	return len(sc) > 0
 }
 
-type DatabaseChanges map[string][]string
+// ColumnChanges holds the breaking statements by the affected columns, keyed by "table.column".
+type ColumnChanges map[string][]string
 
-func (dc DatabaseChanges) add(database string, statements ...string) {
-	dc[database] = append(dc[database], statements...)
+// Columns returns the affected columns as "table.column" in lexical order;
+// BreakingChanges.Findings are in the order of the input.
+func (cc ColumnChanges) Columns() []string {
+	return sortedKeys(cc)
 }
 
-// Databases returns the affected database names.
+// Statements returns the breaking statements for the given column of the table.
+func (cc ColumnChanges) Statements(table, column string) []string {
+	return cc[table+"."+column]
+}
+
+// Exist return if any changes exist.
+func (cc ColumnChanges) Exist() bool {
+	return len(cc) > 0
+}
+
+// ConstraintChanges holds the breaking statements by the affected constraints, foreign keys and indexes of tables,
+// keyed by "table.constraint".
+type ConstraintChanges map[string][]string
+
+// Constraints returns the affected constraints as "table.constraint" in lexical order;
+// BreakingChanges.Findings are in the order of the input.
+func (cc ConstraintChanges) Constraints() []string {
+	return sortedKeys(cc)
+}
+
+// Statements returns the breaking statements for the given constraint of the table.
+func (cc ConstraintChanges) Statements(table, constraint string) []string {
+	return cc[table+"."+constraint]
+}
+
+// Exist return if any changes exist.
+func (cc ConstraintChanges) Exist() bool {
+	return len(cc) > 0
+}
+
+type DatabaseChanges map[string][]string
+
+// Databases returns the affected database names in lexical order;
+// BreakingChanges.Objects lists them in the order they first appear in the input.
 func (dc DatabaseChanges) Databases() []string {
-	return lo.Keys(dc)
+	return sortedKeys(dc)
 }
 
 // Statements returns the breaking statements for the given database.
@@ -140,3 +449,9 @@ func (dc DatabaseChanges) Statements(database string) []string {
 func (dc DatabaseChanges) Exist() bool {
 	return len(dc) > 0
 }
+
+func sortedKeys(m map[string][]string) []string {
+	keys := lo.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,121 @@
+package breaql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakingChanges_Order(t *testing.T) {
+	sql := `DROP TABLE zebra;
+		ALTER TABLE apple DROP COLUMN id;
+		TRUNCATE TABLE zebra;
+		DROP TABLE mango;`
+
+	got, err := breaql.RunMySQL(sql)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"zebra", "apple", "mango"}, got.Objects(breaql.ObjectKindTable))
+	assert.Equal(t, []string{"apple", "mango", "zebra"}, got.Tables.Tables())
+	assert.Equal(t, "-- Table: zebra\n"+
+		"        DROP TABLE zebra;\n"+
+		"        TRUNCATE TABLE zebra;\n"+
+		"-- Table: apple\n"+
+		"        ALTER TABLE apple DROP COLUMN id;\n"+
+		"-- Table: mango\n"+
+		"        DROP TABLE mango;\n", got.FormatSQL())
+
+	sorted := got.Sorted(breaql.OrderName)
+	assert.Equal(t, []string{"apple", "mango", "zebra"}, sorted.Objects(breaql.ObjectKindTable))
+	assert.Equal(t, []string{"mysql/drop-column", "mysql/drop-table", "mysql/drop-table", "mysql/truncate-table"},
+		lo.Map(sorted.Findings, func(f breaql.Finding, _ int) string { return f.RuleID }))
+	assert.Equal(t, "zebra", got.Findings[0].Object, "Sorted must not modify the receiver")
+}
+
+func TestBreakingChanges_Filter(t *testing.T) {
+	changes, err := breaql.RunMySQL(`DROP TABLE users;
+		ALTER TABLE orders DROP COLUMN note, DROP INDEX idx_note;`)
+	assert.NoError(t, err)
+
+	got := changes.Filter(func(f breaql.Finding) bool { return f.RuleID != "mysql/drop-column" })
+
+	assert.Equal(t, []string{"mysql/drop-table", "mysql/drop-index"},
+		lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID }))
+	assert.Equal(t, breaql.TableChanges{
+		"users":  {"DROP TABLE users;"},
+		"orders": {"ALTER TABLE orders DROP COLUMN note, DROP INDEX idx_note;"},
+	}, got.Tables)
+
+	got = changes.Filter(func(f breaql.Finding) bool { return f.Object != "orders" })
+	assert.Equal(t, []string{"users"}, got.Tables.Tables())
+}
+
+func TestBreakingChanges_Columns(t *testing.T) {
+	changes, err := breaql.RunMySQL(`ALTER TABLE users DROP COLUMN age, DROP COLUMN note, DROP FOREIGN KEY fk_team;
+		ALTER TABLE users MODIFY age VARCHAR(10);
+		DROP TABLE orders;`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"users.age", "users.note"}, changes.Columns.Columns())
+	assert.Equal(t, []string{
+		"ALTER TABLE users DROP COLUMN age, DROP COLUMN note, DROP FOREIGN KEY fk_team;",
+		"ALTER TABLE users MODIFY age VARCHAR(10);",
+	}, changes.Columns.Statements("users", "age"))
+	assert.Equal(t, breaql.ConstraintChanges{
+		"users.fk_team": {"ALTER TABLE users DROP COLUMN age, DROP COLUMN note, DROP FOREIGN KEY fk_team;"},
+	}, changes.Constraints)
+	assert.Empty(t, changes.Columns.Statements("orders", "id"), "dropped tables are not broken down by columns")
+
+	changes, err = breaql.RunPostgreSQL(`ALTER TABLE public.users DROP COLUMN age, DROP CONSTRAINT users_team_id_fkey;`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"public.users.age"}, changes.Columns.Columns())
+	assert.Equal(t, []string{"public.users.users_team_id_fkey"}, changes.Constraints.Constraints())
+
+	got := changes.Filter(func(f breaql.Finding) bool { return f.RuleID != "pg/drop-column" })
+	assert.False(t, got.Columns.Exist())
+	assert.True(t, got.Constraints.Exist())
+}
+
+func TestBreakingChanges_ObjectsSkipOperationalRisks(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(sql string, opts breaql.Options) (breaql.BreakingChanges, error)
+		sql  string
+		want string // FormatSQL output
+	}{
+		{
+			name: "PostgreSQL",
+			run:  breaql.RunPostgreSQLWithOptions,
+			sql:  "DROP TABLE a;\nCREATE INDEX i ON big (x);",
+			want: "-- Table: a\n        DROP TABLE a;\n",
+		},
+		{
+			name: "MySQL",
+			run:  breaql.RunMySQLWithOptions,
+			sql:  "DROP TABLE a;\nDELETE t1 FROM t1 JOIN t2 ON t2.id = t1.id;",
+			want: "-- Table: a\n        DROP TABLE a;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.run(tt.sql, breaql.Options{OperationalRisks: true})
+			require.NoError(t, err)
+			require.NotEmpty(t, got.OperationalRisks())
+			assert.Equal(t, []string{"a"}, got.Objects(breaql.ObjectKindTable))
+			assert.Equal(t, tt.want, got.FormatSQL())
+			assert.Equal(t, tt.want, got.Sorted(breaql.OrderName).FormatSQL())
+
+			buf := &bytes.Buffer{}
+			require.NoError(t, breaql.WriteJSON(buf, breaql.Report{Files: []breaql.FileReport{{Path: "-", Changes: got}}}))
+			var report breaql.JSONReport
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+			assert.Equal(t, []breaql.JSONObject{{Kind: breaql.ObjectKindTable, Name: "a", Statements: []string{"DROP TABLE a;"}}}, report.Files[0].Objects)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package breaql
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type (
+	checkstyleResult struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+)
+
+// WriteCheckstyle writes the report to w as Checkstyle XML, e.g. for reviewdog.
+func WriteCheckstyle(w io.Writer, r Report) error {
+	result := checkstyleResult{Version: "8.0"}
+	for _, file := range r.Files {
+		cf := checkstyleFile{Name: file.Path}
+		for _, f := range file.Changes.Findings {
+			cf.Errors = append(cf.Errors, checkstyleError{
+				Line:     f.Position.Line,
+				Column:   f.Position.Column,
+				Severity: checkstyleSeverity(f.Severity),
+				Message:  f.Message,
+				Source:   "breaql." + f.RuleID,
+			})
+		}
+		result.Files = append(result.Files, cf)
+	}
+
+	return writeXML(w, result)
+}
+
+func checkstyleSeverity(s Severity) string {
+	switch {
+	case s >= SeverityError:
+		return "error"
+	case s == SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
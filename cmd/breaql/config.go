@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/alecthomas/kong"
+	"github.com/ebi-yade/breaql"
+	"github.com/pingcap/errors"
+)
+
+// loadConfig loads the config file at the given path, or the one found from the working directory if path is empty.
+// It returns the zero Config if no config file is found.
+func loadConfig(path string) (breaql.Config, error) {
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return breaql.Config{}, errors.Wrap(err, "error os.Getwd")
+		}
+		found, ok, err := breaql.FindConfig(wd)
+		if err != nil {
+			return breaql.Config{}, errors.Wrap(err, "error breaql.FindConfig")
+		}
+		if !ok {
+			return breaql.Config{}, nil
+		}
+		path = found
+	}
+
+	cfg, err := breaql.LoadConfig(path)
+	if err != nil {
+		return breaql.Config{}, errors.Wrap(err, "error breaql.LoadConfig")
+	}
+
+	// Make the paths relative to the working directory.
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return breaql.Config{}, errors.Wrap(err, "error filepath.Abs")
+	}
+	for i, p := range cfg.Paths {
+		if !filepath.IsAbs(p) {
+			cfg.Paths[i] = relativePath(filepath.Join(dir, p))
+		}
+	}
+	if cfg.Baseline != "" && !filepath.IsAbs(cfg.Baseline) {
+		cfg.Baseline = relativePath(filepath.Join(dir, cfg.Baseline))
+	}
+	return cfg, nil
+}
+
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(wd, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// applyConfig fills in the flags not given on the command line with the values in the config.
+func applyConfig(input *Input, kctx *kong.Context, cfg breaql.Config) {
+	given := map[string]bool{}
+	for _, el := range kctx.Path {
+		if el.Flag != nil {
+			given[el.Flag.Name] = true
+		}
+	}
+
+	if !given["driver"] && cfg.Driver != "" {
+		input.Driver = cfg.Driver
+	}
+	if !given["format"] && cfg.Format != "" {
+		input.Format = cfg.Format
+	}
+	if !given["baseline"] && cfg.Baseline != "" {
+		input.Check.Baseline = cfg.Baseline
+	}
+	if !given["fail-on"] && cfg.FailOn != "" {
+		input.FailOn = cfg.FailOn
+	}
+	if !given["require-suppression-reason"] && cfg.RequireSuppressionReason {
+		input.RequireSuppressionReason = true
+	}
+	if !given["operational-risks"] && cfg.OperationalRisks {
+		input.OperationalRisks = true
+	}
+	if !given["mysql-version"] && cfg.MySQLVersion != "" {
+		input.MySQLVersion = cfg.MySQLVersion
+	}
+	if len(input.Check.Paths) == 0 && len(input.Check.Path) == 0 {
+		input.Check.Paths = cfg.Paths
+	}
+}
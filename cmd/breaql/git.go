@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/pingcap/errors"
+)
+
+// gitChange is a SQL file added or modified since the base ref.
+type gitChange struct {
+	Path  string      // relative to the working directory
+	Added bool        // whether the file did not exist at the base ref
+	Lines []lineRange // changed lines of a modified file
+}
+
+// lineRange is a range of lines, both ends inclusive.
+type lineRange struct {
+	From, To int
+}
+
+// gitChanges lists the SQL files added or modified on the current branch,
+// i.e. between the merge base of baseRef and HEAD, and the working tree, which is what gets analyzed.
+// Untracked files are not listed until they are added to the index.
+// Renames are reported as additions so that moved files are analyzed in full.
+func gitChanges(baseRef string) ([]gitChange, error) {
+	out, err := git("merge-base", baseRef, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	mergeBase := strings.TrimSpace(out)
+
+	out, err = git("diff", "--relative", "--no-renames", "--name-status", "--diff-filter=AM", "-z", mergeBase)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []gitChange
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, path := fields[i], filepath.FromSlash(fields[i+1])
+		if !strings.EqualFold(filepath.Ext(path), ".sql") {
+			continue
+		}
+		change := gitChange{Path: path, Added: status == "A"}
+		if !change.Added {
+			if change.Lines, err = gitChangedLines(mergeBase, path); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+var hunkHeader = regexp.MustCompile(`(?m)^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// gitChangedLines returns the lines of the file in the working tree that differ from the given commit.
+func gitChangedLines(commit, path string) ([]lineRange, error) {
+	out, err := git("diff", "--relative", "--no-renames", "--unified=0", commit, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	return parseHunks(out), nil
+}
+
+// parseHunks returns the lines of the new file changed by the hunks of a diff with --unified=0.
+func parseHunks(diff string) []lineRange {
+	var lines []lineRange
+	for _, m := range hunkHeader.FindAllStringSubmatch(diff, -1) {
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		if count == 0 {
+			// Lines were only deleted after the start line, which changes the statements around it.
+			lines = append(lines, lineRange{From: start, To: start + 1})
+			continue
+		}
+		lines = append(lines, lineRange{From: start, To: start + count - 1})
+	}
+	return lines
+}
+
+// touches returns whether the finding's statement spans any of the changed lines.
+func (c gitChange) touches(f breaql.Finding) bool {
+	for _, r := range c.Lines {
+		if f.Position.Line <= r.To && r.From <= f.End.Line {
+			return true
+		}
+	}
+	return false
+}
+
+func git(args ...string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "error git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
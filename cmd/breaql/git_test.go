@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHunks(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want []lineRange
+	}{
+		{
+			name: "NoHunks",
+			diff: "",
+			want: nil,
+		},
+		{
+			name: "AddedLines",
+			diff: "@@ -3,0 +4,2 @@\n+ALTER TABLE users DROP COLUMN age;\n+DROP TABLE posts;\n",
+			want: []lineRange{{From: 4, To: 5}},
+		},
+		{
+			name: "SingleLineWithoutCount",
+			diff: "@@ -7 +7 @@\n-DROP TABLE a;\n+DROP TABLE b;\n",
+			want: []lineRange{{From: 7, To: 7}},
+		},
+		{
+			name: "DeletedLines",
+			diff: "@@ -5,2 +4,0 @@\n-DROP TABLE a;\n-DROP TABLE b;\n",
+			want: []lineRange{{From: 4, To: 5}},
+		},
+		{
+			name: "MultipleHunks",
+			diff: "diff --git a/001.sql b/001.sql\n--- a/001.sql\n+++ b/001.sql\n@@ -1 +1,3 @@ CREATE TABLE users (\n-x\n+y\n+z\n+w\n@@ -10,0 +12 @@\n+v\n",
+			want: []lineRange{{From: 1, To: 3}, {From: 12, To: 12}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseHunks(tt.diff)
+			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("parseHunks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGitChange_Touches(t *testing.T) {
+	change := gitChange{Path: "001.sql", Lines: []lineRange{{From: 4, To: 5}, {From: 10, To: 10}}}
+	tests := []struct {
+		name       string
+		start, end int
+		want       bool
+	}{
+		{name: "Before", start: 1, end: 3, want: false},
+		{name: "EndsOnFirstLine", start: 2, end: 4, want: true},
+		{name: "Inside", start: 5, end: 5, want: true},
+		{name: "Between", start: 6, end: 9, want: false},
+		{name: "Spanning", start: 8, end: 12, want: true},
+		{name: "After", start: 11, end: 12, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := breaql.Finding{Position: breaql.Position{Line: tt.start}, End: breaql.Position{Line: tt.end}}
+			assert.Equal(t, tt.want, change.touches(f))
+		})
+	}
+}
+
+func TestGitChanges_WorkingTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	run := func(args ...string) {
+		t.Helper()
+		_, err := git(append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		require.NoError(t, err)
+	}
+	write := func(path, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644))
+	}
+
+	run("init", "--quiet", "--initial-branch=main")
+	write("001.sql", "CREATE TABLE users (id INT);\n")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "base")
+	run("checkout", "--quiet", "-b", "feature")
+
+	// Uncommitted changes to tracked files are analyzed as they are read from the working tree.
+	write("001.sql", "CREATE TABLE users (id INT);\nDROP TABLE users;\n")
+	write("002.sql", "DROP TABLE posts;\n")
+	run("add", "002.sql")
+	write("003.sql", "DROP TABLE comments;\n")
+
+	got, err := gitChanges("main")
+	require.NoError(t, err)
+	want := []gitChange{
+		{Path: "001.sql", Lines: []lineRange{{From: 2, To: 2}}},
+		{Path: "002.sql", Added: true},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("gitChanges() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/pingcap/errors"
+)
+
+// expandPaths resolves the given paths, directories and glob patterns into SQL files.
+// Directories are searched recursively for *.sql files. "-" stands for the standard input.
+func expandPaths(patterns []string) ([]string, error) {
+	var paths []string
+	add := func(path string) {
+		if path != "-" {
+			path = filepath.Clean(path)
+		}
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if pattern == "-" {
+			add(pattern)
+			continue
+		}
+
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			matches, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error filepath.Glob %s", pattern)
+			}
+			if len(matches) == 0 {
+				return nil, errors.Errorf("no files match %s", pattern)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, errors.Wrap(err, "error os.Stat")
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".sql") {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "error filepath.WalkDir")
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// analyzePaths analyzes the SQL files at the given paths, or the standard input if none is given.
+func analyzePaths(driver string, opts breaql.Options, patterns []string) (breaql.Report, error) {
+	paths, err := expandPaths(patterns)
+	if err != nil {
+		return breaql.Report{}, errors.Wrap(err, "error expandPaths")
+	}
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	report := breaql.Report{Driver: driver}
+	for _, path := range paths {
+		file, err := analyzeFile(driver, opts, path)
+		if err != nil {
+			return breaql.Report{}, errors.Wrapf(err, "error analyzeFile %s", path)
+		}
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
+
+// analyzeGitChanges analyzes the SQL files added or modified since baseRef.
+// If patterns are given, only the files matching them are analyzed.
+func analyzeGitChanges(driver string, opts breaql.Options, baseRef string, patterns []string) (breaql.Report, error) {
+	changes, err := gitChanges(baseRef)
+	if err != nil {
+		return breaql.Report{}, errors.Wrap(err, "error gitChanges")
+	}
+	if len(patterns) > 0 {
+		paths, err := expandPaths(patterns)
+		if err != nil {
+			return breaql.Report{}, errors.Wrap(err, "error expandPaths")
+		}
+		changes = slices.DeleteFunc(changes, func(c gitChange) bool {
+			return !slices.Contains(paths, c.Path)
+		})
+	}
+
+	report := breaql.Report{Driver: driver}
+	for _, change := range changes {
+		file, err := analyzeFile(driver, opts, change.Path)
+		if err != nil {
+			return breaql.Report{}, errors.Wrapf(err, "error analyzeFile %s", change.Path)
+		}
+		if !change.Added {
+			file.Changes = file.Changes.Filter(change.touches)
+		}
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
+
+// analyzeFile reads the DDL statements from the given path and detects the breaking ones.
+func analyzeFile(driver string, opts breaql.Options, path string) (breaql.FileReport, error) {
+	var ddlReader io.Reader
+	if path == "-" {
+		ddlReader = os.Stdin
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return breaql.FileReport{}, errors.Wrap(err, "error os.Open")
+		}
+		defer file.Close()
+		ddlReader = file
+	}
+	ddl, err := io.ReadAll(ddlReader)
+	if err != nil {
+		return breaql.FileReport{}, errors.Wrap(err, "error io.ReadAll")
+	}
+
+	changes, err := analyze(driver, opts, string(ddl))
+	if err != nil {
+		return breaql.FileReport{}, err
+	}
+	return breaql.FileReport{Path: path, Changes: changes}, nil
+}
+
+// analyze detects the breaking changes in the given DDL statements.
+func analyze(driver string, opts breaql.Options, ddl string) (breaql.BreakingChanges, error) {
+	switch driver {
+	case "mysql":
+		changes, err := breaql.RunMySQLWithOptions(ddl, opts)
+		if err != nil {
+			return breaql.BreakingChanges{}, errors.Wrap(err, "error breaql.RunMySQLWithOptions")
+		}
+		return changes, nil
+	case "pg":
+		changes, err := breaql.RunPostgreSQLWithOptions(ddl, opts)
+		if err != nil {
+			return breaql.BreakingChanges{}, errors.Wrap(err, "error breaql.RunPostgreSQLWithOptions")
+		}
+		return changes, nil
+	default:
+		return breaql.BreakingChanges{}, errors.Errorf("unsupported driver: %s", driver)
+	}
+}
+
+// loadSchema reads the schema dump at the given path.
+func loadSchema(driver string, path string) (*breaql.Schema, error) {
+	dump, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error os.ReadFile")
+	}
+
+	switch driver {
+	case "mysql":
+		schema, err := breaql.LoadMySQLSchema(string(dump))
+		if err != nil {
+			return nil, errors.Wrap(err, "error breaql.LoadMySQLSchema")
+		}
+		return schema, nil
+	case "pg":
+		schema, err := breaql.LoadPostgreSQLSchema(string(dump))
+		if err != nil {
+			return nil, errors.Wrap(err, "error breaql.LoadPostgreSQLSchema")
+		}
+		return schema, nil
+	default:
+		return nil, errors.Errorf("baseline is not supported for driver: %s", driver)
+	}
+}
+
+// analyzeDiff detects the breaking changes made by migrating the schema snapshot at oldPath to the one at newPath.
+// The findings are reported on the new snapshot.
+func analyzeDiff(driver string, opts breaql.Options, oldPath, newPath string) (breaql.Report, error) {
+	oldSchema, err := loadSchema(driver, oldPath)
+	if err != nil {
+		return breaql.Report{}, errors.Wrapf(err, "error loadSchema %s", oldPath)
+	}
+	newSchema, err := loadSchema(driver, newPath)
+	if err != nil {
+		return breaql.Report{}, errors.Wrapf(err, "error loadSchema %s", newPath)
+	}
+
+	changes, err := breaql.DiffWithOptions(oldSchema, newSchema, opts)
+	if err != nil {
+		return breaql.Report{}, errors.Wrap(err, "error breaql.DiffWithOptions")
+	}
+	return breaql.Report{Driver: driver, Files: []breaql.FileReport{{Path: newPath, Changes: changes}}}, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"001.sql", "002.SQL", "README.md", "seeds/users.sql", "seeds/nested/posts.sql", "seeds/notes.txt"} {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+	p := func(path string) string { return filepath.Join(dir, path) }
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "None",
+			patterns: nil,
+			want:     nil,
+		},
+		{
+			name:     "Stdin",
+			patterns: []string{"-"},
+			want:     []string{"-"},
+		},
+		{
+			name:     "File",
+			patterns: []string{p("README.md")},
+			want:     []string{p("README.md")},
+		},
+		{
+			name:     "Glob",
+			patterns: []string{p("*.sql")},
+			want:     []string{p("001.sql")},
+		},
+		{
+			name:     "Directory",
+			patterns: []string{p("seeds")},
+			want:     []string{p("seeds/nested/posts.sql"), p("seeds/users.sql")},
+		},
+		{
+			name:     "GlobOfDirectories",
+			patterns: []string{p("see*")},
+			want:     []string{p("seeds/nested/posts.sql"), p("seeds/users.sql")},
+		},
+		{
+			name:     "Duplicates",
+			patterns: []string{p("001.sql"), p("*.sql"), dir + "/./001.sql", p("seeds/users.sql"), p("seeds"), "-", "-"},
+			want:     []string{p("001.sql"), p("seeds/users.sql"), p("seeds/nested/posts.sql"), "-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandPaths(tt.patterns)
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("expandPaths() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExpandPaths_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := expandPaths([]string{filepath.Join(dir, "*.sql")})
+	assert.ErrorContains(t, err, "no files match")
+
+	_, err = expandPaths([]string{filepath.Join(dir, "missing.sql")})
+	assert.ErrorContains(t, err, "error os.Stat")
+}
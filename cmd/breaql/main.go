@@ -2,30 +2,70 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 
 	"github.com/alecthomas/kong"
 	"github.com/ebi-yade/breaql"
 	"github.com/pingcap/errors"
+	"github.com/samber/lo"
+)
+
+// Exit codes of the CLI.
+const (
+	exitOK       = 0 // no findings reach the --fail-on threshold
+	exitBreaking = 1 // some findings reach the --fail-on threshold
+	exitError    = 2 // the input could not be parsed or the CLI was misused
 )
 
 type Input struct {
-	Driver   string `name:"driver" default:"mysql" help:"Database driver (mysql, pg)"`
-	Path     string `name:"path" default:"-" help:"Path to the SQL file"`
-	LogLevel string `name:"log-level" default:"info" help:"Log level"`
+	Driver                   string `name:"driver" default:"mysql" help:"Database driver (mysql, pg)"`
+	Format                   string `name:"format" default:"sql" enum:"sql,json,sarif,github,junit,checkstyle" help:"Output format (sql, json, sarif, github, junit, checkstyle)"`
+	Sort                     string `name:"sort" default:"source" enum:"source,name" help:"Order of the reported objects (source, name)"`
+	FailOn                   string `name:"fail-on" default:"info" help:"Severity (info, warning, error, critical) and/or comma-separated rule IDs whose findings make the CLI exit with 1"`
+	RequireSuppressionReason bool   `name:"require-suppression-reason" help:"Ignore breaql:ignore comments without reason=\"...\""`
+	OperationalRisks         bool   `name:"operational-risks" help:"Also report statements that may cause an outage while running, e.g. by holding locks"`
+	MySQLVersion             string `name:"mysql-version" placeholder:"VERSION" help:"Target MySQL version of --operational-risks, e.g. 5.7, 8.0 or 8.0.28 (default: 8.0)"`
+	Config                   string `name:"config" placeholder:"PATH" help:"Path to the config file (default: .breaql.yaml in the working directory or its ancestors)"`
+	LogLevel                 string `name:"log-level" default:"info" help:"Log level"`
+
+	Check CheckCmd `cmd:"" default:"withargs" help:"Analyze migration files (default)"`
+	Diff  DiffCmd  `cmd:"" help:"Compare two schema snapshots, e.g. schema.sql before and after a declarative schema change"`
+}
+
+type CheckCmd struct {
+	Paths    []string `arg:"" optional:"" name:"paths" help:"SQL files, directories or glob patterns to analyze (default: stdin)"`
+	Path     []string `name:"path" help:"Same as the positional arguments (repeatable)"`
+	GitDiff  string   `name:"git-diff" placeholder:"BASE-REF" help:"Only analyze SQL files added or modified since the merge base with BASE-REF; for modified files, only the changed statements are reported"`
+	Baseline string   `name:"baseline" placeholder:"PATH" help:"Schema dump before the migrations (e.g. mysqldump --no-data) to classify column changes"`
+}
+
+type DiffCmd struct {
+	Old string `arg:"" name:"old" help:"Schema snapshot before the change"`
+	New string `arg:"" name:"new" help:"Schema snapshot after the change"`
 }
 
-func main_() error {
+// main_ runs the CLI and returns whether any finding reaches the --fail-on threshold.
+func main_() (bool, error) {
 	input := Input{}
 	flagParser, err := kong.New(&input, kong.UsageOnError())
 	if err != nil {
-		return errors.Wrap(err, "error kong.New")
+		return false, errors.Wrap(err, "error kong.New")
 	}
-	_, err = flagParser.Parse(os.Args[1:])
+	kctx, err := flagParser.Parse(os.Args[1:])
+	if err != nil {
+		return false, errors.Wrap(err, "error flagParser.Parse")
+	}
+
+	cfg, err := loadConfig(input.Config)
+	if err != nil {
+		return false, errors.Wrap(err, "error loadConfig")
+	}
+	applyConfig(&input, kctx, cfg)
+
+	threshold, err := breaql.ParseThreshold(input.FailOn)
 	if err != nil {
-		return errors.Wrap(err, "error flagParser.Parse")
+		return false, errors.Wrap(err, "error breaql.ParseThreshold")
 	}
 
 	switch input.LogLevel {
@@ -34,60 +74,72 @@ func main_() error {
 	case "info":
 		slog.SetLogLoggerLevel(slog.LevelInfo)
 	default:
-		return errors.Errorf("invalid log level: %s", input.LogLevel)
+		return false, errors.Errorf("invalid log level: %s", input.LogLevel)
 	}
 
-	// Read the DDLs
-	var ddlReader io.Reader
-	if input.Path == "-" {
-		ddlReader = os.Stdin
-	} else {
-		file, err := os.Open(input.Path)
+	// Read and analyze the DDLs
+	opts := cfg.Options()
+	opts.RequireSuppressionReason = input.RequireSuppressionReason
+	opts.OperationalRisks = input.OperationalRisks
+	opts.MySQLVersion = input.MySQLVersion
+	var report breaql.Report
+	switch kctx.Selected().Name {
+	case "diff":
+		report, err = analyzeDiff(input.Driver, opts, input.Diff.Old, input.Diff.New)
 		if err != nil {
-			return errors.Wrap(err, "error os.Open")
+			return false, errors.Wrap(err, "error analyzeDiff")
+		}
+	default:
+		if input.Check.Baseline != "" {
+			opts.Baseline, err = loadSchema(input.Driver, input.Check.Baseline)
+			if err != nil {
+				return false, errors.Wrap(err, "error loadSchema")
+			}
+		}
+		patterns := append(input.Check.Path, input.Check.Paths...)
+		if input.Check.GitDiff != "" {
+			report, err = analyzeGitChanges(input.Driver, opts, input.Check.GitDiff, patterns)
+			if err != nil {
+				return false, errors.Wrap(err, "error analyzeGitChanges")
+			}
+		} else {
+			report, err = analyzePaths(input.Driver, opts, patterns)
+			if err != nil {
+				return false, errors.Wrap(err, "error analyzePaths")
+			}
 		}
-		defer file.Close()
-		ddlReader = file
 	}
-	ddl, err := io.ReadAll(ddlReader)
-	if err != nil {
-		return errors.Wrap(err, "error io.ReadAll")
+	switch input.Sort {
+	case "source":
+		report = report.Sorted(breaql.OrderSource)
+	case "name":
+		report = report.Sorted(breaql.OrderName)
 	}
 
-	// Detect destructive changes
-	var changes breaql.BreakingChanges
-	switch input.Driver {
-	case "mysql":
-		changes, err = breaql.RunMySQL(string(ddl))
-		if err != nil {
-			return errors.Wrap(err, "error breaql.RunMySQL")
-		}
-	case "pg":
-		changes, err = breaql.RunPostgreSQL(string(ddl))
-		if err != nil {
-			return errors.Wrap(err, "error breaql.RunPostgreSQL")
-		}
-	default:
-		return errors.Errorf("unsupported driver: %s", input.Driver)
+	reporter, err := breaql.NewReporter(input.Format)
+	if err != nil {
+		return false, errors.Wrap(err, "error breaql.NewReporter")
 	}
-	if changes.Exist() {
-		fmt.Println("-- Detected destructive changes:")
-		fmt.Printf(changes.FormatSQL())
-	} else {
-		fmt.Println("-- No destructive changes detected. --")
+	if err := reporter.Report(os.Stdout, report); err != nil {
+		return false, errors.Wrap(err, "error reporter.Report")
 	}
 
-	return nil
+	return lo.SomeBy(report.Findings(), threshold.Match), nil
 }
 
 func main() {
-	if err := main_(); err != nil {
-		switch err := errors.Cause(err).(type) {
+	failed, err := main_()
+	if err != nil {
+		switch cause := errors.Cause(err).(type) {
 		case *breaql.ParseError:
-			slog.Error("Parse Error!", slog.String("message", err.Message))
+			slog.Error("Parse Error!", slog.String("message", cause.Message), slog.String("error", err.Error()))
 		default:
 			slog.Error(fmt.Sprintf("error: %v", err))
 		}
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+	if failed {
+		os.Exit(exitBreaking)
 	}
+	os.Exit(exitOK)
 }
@@ -0,0 +1,134 @@
+package breaql
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the project configuration file.
+const ConfigFileName = ".breaql.yaml"
+
+// Config is the project configuration, usually loaded from ConfigFileName:
+//
+//	driver: pg
+//	paths:
+//	  - db/migrations/*.sql
+//	format: github
+//	baseline: db/schema.sql
+//	fail_on: error
+//	require_suppression_reason: true
+//	operational_risks: true
+//	mysql_version: "5.7"
+//	rules:
+//	  disabled: [pg/drop-index]
+//	  severity:
+//	    pg/alter-column-type: error
+//	objects:
+//	  allow: ["tmp_*"]
+//	  deny: [tmp_important]
+type Config struct {
+	Driver                   string        `yaml:"driver"`   // "mysql" or "pg"
+	Paths                    []string      `yaml:"paths"`    // files, directories or glob patterns relative to the config file
+	Format                   string        `yaml:"format"`   // output format of the CLI
+	Baseline                 string        `yaml:"baseline"` // schema dump relative to the config file; see Options.Baseline
+	FailOn                   string        `yaml:"fail_on"`  // see ParseThreshold
+	RequireSuppressionReason bool          `yaml:"require_suppression_reason"`
+	OperationalRisks         bool          `yaml:"operational_risks"` // see Options.OperationalRisks
+	MySQLVersion             string        `yaml:"mysql_version"`     // see Options.MySQLVersion
+	Rules                    RulesConfig   `yaml:"rules"`
+	Objects                  ObjectsConfig `yaml:"objects"`
+}
+
+// RulesConfig selects the rules and overrides their severities.
+type RulesConfig struct {
+	Enabled  []string            `yaml:"enabled"`  // patterns of the rules to run; empty means all
+	Disabled []string            `yaml:"disabled"` // patterns of the rules not to run
+	Severity map[string]Severity `yaml:"severity"` // severities by rule ID
+}
+
+// ObjectsConfig lists the objects that are allowed to be broken.
+type ObjectsConfig struct {
+	Allow []string `yaml:"allow"` // patterns of the object names whose findings are dropped
+	Deny  []string `yaml:"deny"`  // patterns of the object names that are never allowed
+}
+
+// LoadConfig reads the configuration file at the given path.
+func LoadConfig(filename string) (Config, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	defer file.Close()
+
+	var cfg Config
+	dec := yaml.NewDecoder(file)
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("error decoding %s: %w", filename, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+func (c Config) validate() error {
+	for _, pattern := range slices.Concat(c.Rules.Enabled, c.Rules.Disabled, c.Objects.Allow, c.Objects.Deny) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", pattern, err)
+		}
+	}
+	if _, err := parseMySQLVersion(c.MySQLVersion); err != nil {
+		return err
+	}
+	for ruleID := range c.Rules.Severity {
+		if _, ok := LookupRule(ruleID); !ok {
+			return fmt.Errorf("unknown rule: %s", ruleID)
+		}
+	}
+	return nil
+}
+
+// FindConfig looks for ConfigFileName in dir and its ancestors, and returns its path if found.
+func FindConfig(dir string) (string, bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false, err
+	}
+	for {
+		filename := filepath.Join(dir, ConfigFileName)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, true, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", false, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false, nil
+		}
+		dir = parent
+	}
+}
+
+// Options returns the analysis options set in the configuration.
+func (c Config) Options() Options {
+	return Options{
+		RequireSuppressionReason: c.RequireSuppressionReason,
+		OperationalRisks:         c.OperationalRisks,
+		MySQLVersion:             c.MySQLVersion,
+		EnabledRules:             c.Rules.Enabled,
+		DisabledRules:            c.Rules.Disabled,
+		Severities:               c.Rules.Severity,
+		AllowedObjects:           c.Objects.Allow,
+		DeniedObjects:            c.Objects.Deny,
+	}
+}
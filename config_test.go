@@ -0,0 +1,93 @@
+package breaql_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, breaql.ConfigFileName)
+	require.NoError(t, os.WriteFile(filename, []byte(`
+driver: pg
+paths: [db/migrations]
+format: sarif
+fail_on: error
+operational_risks: true
+mysql_version: "5.7"
+rules:
+  disabled: ["pg/rename-*"]
+  severity:
+    pg/drop-index: error
+objects:
+  allow: ["tmp_*"]
+  deny: [tmp_keep]
+`), 0o644))
+
+	got, err := breaql.LoadConfig(filename)
+	require.NoError(t, err)
+	assert.Equal(t, breaql.Config{
+		Driver:           "pg",
+		Paths:            []string{"db/migrations"},
+		Format:           "sarif",
+		FailOn:           "error",
+		OperationalRisks: true,
+		MySQLVersion:     "5.7",
+		Rules: breaql.RulesConfig{
+			Disabled: []string{"pg/rename-*"},
+			Severity: map[string]breaql.Severity{"pg/drop-index": breaql.SeverityError},
+		},
+		Objects: breaql.ObjectsConfig{Allow: []string{"tmp_*"}, Deny: []string{"tmp_keep"}},
+	}, got)
+
+	nested := filepath.Join(dir, "db", "migrations")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+	found, ok, err := breaql.FindConfig(nested)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, filename, found)
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"UnknownField":    "drivers: pg\n",
+		"UnknownRule":     "rules:\n  severity:\n    pg/drop-everything: error\n",
+		"UnknownSeverity": "rules:\n  severity:\n    pg/drop-index: fatal\n",
+		"BadPattern":      "objects:\n  allow: [\"[\"]\n",
+		"BadMySQLVersion": "mysql_version: \"8\"\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), breaql.ConfigFileName)
+			require.NoError(t, os.WriteFile(filename, []byte(content), 0o644))
+			_, err := breaql.LoadConfig(filename)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	sql := `DROP TABLE tmp_users;
+		DROP TABLE tmp_keep;
+		ALTER TABLE users DROP COLUMN age, DROP INDEX idx_age;`
+
+	got, err := breaql.RunMySQLWithOptions(sql, breaql.Options{
+		DisabledRules:  []string{"mysql/drop-index"},
+		Severities:     map[string]breaql.Severity{"mysql/drop-column": breaql.SeverityCritical},
+		AllowedObjects: []string{"tmp_*"},
+		DeniedObjects:  []string{"tmp_keep"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql/drop-table tmp_keep error", "mysql/drop-column users critical"},
+		lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID + " " + f.Object + " " + f.Severity.String() }))
+
+	got, err = breaql.RunMySQLWithOptions(sql, breaql.Options{EnabledRules: []string{"mysql/drop-i*"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql/drop-index"}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID }))
+}
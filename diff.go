@@ -0,0 +1,256 @@
+package breaql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Diff compares two snapshots of a schema, e.g. schema.sql before and after a change managed by a declarative tool,
+// and returns the breaking changes that migrating from old to new makes.
+// Both must be loaded for the same driver, by LoadMySQLSchema or LoadPostgreSQLSchema.
+//
+// A table or column that disappears while another one with the same definition appears is reported as renamed.
+// The findings point to the CREATE TABLE statements in new, and their statements are the equivalent DDL.
+func Diff(old, new *Schema) (BreakingChanges, error) {
+	return DiffWithOptions(old, new, Options{})
+}
+
+// DiffWithOptions is like Diff but customizes the analysis with opts. opts.Baseline is not used.
+func DiffWithOptions(old, new *Schema, opts Options) (BreakingChanges, error) {
+	if old == nil || new == nil || old.driver != new.driver {
+		return BreakingChanges{}, errors.New("schemas to compare must be loaded for the same driver")
+	}
+
+	d := &differ{driver: old.driver, a: newAnalysis(opts)}
+	var removed []*schemaTable
+	for _, ot := range old.tables {
+		if nt := new.table(ot.name); nt != nil {
+			d.table(ot, nt)
+		} else {
+			removed = append(removed, ot)
+		}
+	}
+	added := slices.DeleteFunc(slices.Clone(new.tables), func(nt *schemaTable) bool { return old.table(nt.name) != nil })
+
+	for _, ot := range removed {
+		i := slices.IndexFunc(added, func(nt *schemaTable) bool { return sameColumns(ot, nt) })
+		if i < 0 {
+			d.add(statement{text: fmt.Sprintf("DROP TABLE %s;", ot.name)}, "drop-table", ObjectKindTable, ot.name, "",
+				fmt.Sprintf("table %s is dropped", ot.name))
+			continue
+		}
+		nt := added[i]
+		added = slices.Delete(added, i, i+1)
+		d.addRename(d.stmt(nt, d.renameTableDDL(ot.name, nt.name)), "rename-table", ObjectKindTable, ot.name, "", "", nt.name,
+			fmt.Sprintf("table %s is renamed to %s", ot.name, nt.name))
+		d.table(ot, nt)
+	}
+
+	return d.a.changes, nil
+}
+
+// differ compares the objects of two snapshots and records the findings.
+type differ struct {
+	driver string
+	a      *analysis
+}
+
+// stmt returns the statement of the finding on the table in the new snapshot.
+func (d *differ) stmt(nt *schemaTable, ddl string) statement {
+	stmt := nt.stmt
+	stmt.text = ddl
+	return stmt
+}
+
+func (d *differ) add(stmt statement, rule string, kind ObjectKind, object, column, message string) {
+	d.a.add(d.finding(stmt, rule, kind, object, column, "", message))
+}
+
+// addConstraint records a finding on a constraint, foreign key or index of the table.
+func (d *differ) addConstraint(stmt statement, rule string, table, constraint, message string) {
+	d.a.add(d.finding(stmt, rule, ObjectKindTable, table, "", constraint, message))
+}
+
+// addRename records the renaming of an object, or of a column or constraint if either is given.
+func (d *differ) addRename(stmt statement, rule string, kind ObjectKind, object, column, constraint, newName, message string) {
+	f := d.finding(stmt, rule, kind, object, column, constraint, message)
+	f.NewName = newName
+	d.a.add(f)
+}
+
+func (d *differ) finding(stmt statement, rule string, kind ObjectKind, object, column, constraint, message string) Finding {
+	f := stmt.finding(d.driver+"/"+rule, kind, object, message)
+	f.Column = column
+	f.Constraint = constraint
+	return f
+}
+
+// table compares the columns, indexes and constraints of a table.
+func (d *differ) table(ot, nt *schemaTable) {
+	table := nt.name
+
+	for i, oc := range ot.columns {
+		nc := nt.column(oc.name)
+		if nc != nil {
+			d.column(ot, nt, oc, nc)
+			continue
+		}
+		// A column replaced by the same definition at the same position is renamed.
+		if i < len(nt.columns) && ot.column(nt.columns[i].name) == nil && sameColumn(oc, nt.columns[i]) {
+			nc = nt.columns[i]
+			d.addRename(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", table, oc.name, nc.name)), "rename-column", ObjectKindTable, table, oc.name, "", nc.name,
+				fmt.Sprintf("column %s of table %s is renamed to %s", oc.name, table, nc.name))
+			continue
+		}
+		d.add(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, oc.name)), "drop-column", ObjectKindTable, table, oc.name,
+			fmt.Sprintf("column %s is dropped from table %s", oc.name, table))
+	}
+
+	for _, oi := range ot.indexes {
+		ni := nt.index(oi.name)
+		switch {
+		case ni != nil && sameIndex(oi, ni):
+		case ni != nil:
+			d.dropIndex(nt, oi, true)
+		default:
+			renamed := slices.IndexFunc(nt.indexes, func(ni *schemaIndex) bool { return ot.index(ni.name) == nil && sameIndex(oi, ni) })
+			if renamed < 0 {
+				d.dropIndex(nt, oi, false)
+				continue
+			}
+			d.renameIndex(nt, oi, nt.indexes[renamed])
+		}
+	}
+
+	for _, oc := range ot.constraints {
+		nc := nt.constraint(oc.name)
+		switch {
+		case nc != nil && sameConstraint(oc, nc):
+		case nc != nil:
+			d.dropConstraint(nt, oc, true)
+		default:
+			renamed := slices.IndexFunc(nt.constraints, func(nc *schemaConstraint) bool {
+				return ot.constraint(nc.name) == nil && sameConstraint(oc, nc)
+			})
+			if renamed < 0 || d.driver == "mysql" {
+				d.dropConstraint(nt, oc, false)
+				continue
+			}
+			nc = nt.constraints[renamed]
+			d.addRename(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s RENAME CONSTRAINT %s TO %s;", table, oc.name, nc.name)), "rename-constraint", ObjectKindTable, table, "", oc.name, nc.name,
+				fmt.Sprintf("constraint %s of table %s is renamed to %s", oc.name, table, nc.name))
+		}
+	}
+}
+
+// column compares the definitions of a column kept in the new snapshot.
+func (d *differ) column(ot, nt *schemaTable, oc, nc *schemaColumn) {
+	table := nt.name
+	switch d.driver {
+	case "mysql":
+		ddl := fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", table, nc.name, nc.typ)
+		if nc.notNull {
+			ddl += " NOT NULL"
+		}
+		for _, f := range mysqlColumnFindings(d.stmt(nt, ddl+";"), table, ot, nt, oc, nc) {
+			d.a.add(f)
+		}
+	case "pg":
+		ddl := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, nc.name, strings.ToLower(nc.typ.String()))
+		for _, f := range pgColumnFindings(d.stmt(nt, ddl), table, oc, nc.typ, false) {
+			d.a.add(f)
+		}
+		if !oc.notNull && nc.notNull {
+			f := d.stmt(nt, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, nc.name)).finding("pg/column-set-not-null", ObjectKindTable, table,
+				fmt.Sprintf("column %s of table %s becomes NOT NULL, which fails on existing NULL values and rejects writers omitting it", nc.name, table))
+			f.Column = nc.name
+			d.a.add(f)
+		}
+	}
+}
+
+// dropIndex records the index dropped from the table, or redefined under the same name.
+func (d *differ) dropIndex(nt *schemaTable, oi *schemaIndex, redefined bool) {
+	message := dropMessage("index "+oi.name, nt.name, redefined)
+	switch d.driver {
+	case "mysql":
+		d.addConstraint(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s DROP INDEX %s;", nt.name, oi.name)), "drop-index", nt.name, oi.name, message)
+	case "pg":
+		index := pgIndexName(nt, oi.name)
+		d.add(d.stmt(nt, fmt.Sprintf("DROP INDEX %s;", index)), "drop-index", ObjectKindIndex, index, "", message)
+	}
+}
+
+func (d *differ) renameIndex(nt *schemaTable, oi, ni *schemaIndex) {
+	switch d.driver {
+	case "mysql":
+		d.addRename(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s RENAME INDEX %s TO %s;", nt.name, oi.name, ni.name)), "rename-index", ObjectKindTable, nt.name, "", oi.name, ni.name,
+			fmt.Sprintf("index %s of table %s is renamed to %s", oi.name, nt.name, ni.name))
+	case "pg":
+		index := pgIndexName(nt, oi.name)
+		d.addRename(d.stmt(nt, fmt.Sprintf("ALTER INDEX %s RENAME TO %s;", index, ni.name)), "rename-index", ObjectKindIndex, index, "", "", ni.name,
+			fmt.Sprintf("index %s is renamed to %s", index, ni.name))
+	}
+}
+
+// dropConstraint records the constraint dropped from the table, or redefined under the same name.
+func (d *differ) dropConstraint(nt *schemaTable, oc *schemaConstraint, redefined bool) {
+	if d.driver == "pg" {
+		d.addConstraint(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", nt.name, oc.name)), "drop-constraint", nt.name, oc.name,
+			dropMessage("constraint "+oc.name, nt.name, redefined))
+		return
+	}
+	switch oc.kind {
+	case constraintPrimaryKey:
+		d.addConstraint(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", nt.name)), "drop-primary-key", nt.name, oc.name,
+			dropMessage("primary key", nt.name, redefined))
+	case constraintForeignKey:
+		d.addConstraint(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", nt.name, oc.name)), "drop-foreign-key", nt.name, oc.name,
+			dropMessage("foreign key "+oc.name, nt.name, redefined))
+	default:
+		d.addConstraint(d.stmt(nt, fmt.Sprintf("ALTER TABLE %s DROP CHECK %s;", nt.name, oc.name)), "drop-check-constraint", nt.name, oc.name,
+			dropMessage("check constraint "+oc.name, nt.name, redefined))
+	}
+}
+
+func dropMessage(object, table string, redefined bool) string {
+	if redefined {
+		return fmt.Sprintf("%s of table %s is redefined", object, table)
+	}
+	return fmt.Sprintf("%s is dropped from table %s", object, table)
+}
+
+func (d *differ) renameTableDDL(old, new string) string {
+	if d.driver == "pg" {
+		return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", old, unqualified(new))
+	}
+	return fmt.Sprintf("RENAME TABLE %s TO %s;", old, new)
+}
+
+// pgIndexName returns the name of the index qualified by the schema of its table.
+func pgIndexName(t *schemaTable, index string) string {
+	return strings.TrimSuffix(t.name, unqualified(t.name)) + index
+}
+
+func sameColumns(a, b *schemaTable) bool {
+	return len(a.columns) > 0 && slices.EqualFunc(a.columns, b.columns, func(x, y *schemaColumn) bool {
+		return strings.EqualFold(x.name, y.name) && sameColumn(x, y)
+	})
+}
+
+// sameColumn returns whether the columns have the same definition regardless of their names.
+func sameColumn(a, b *schemaColumn) bool {
+	return a.notNull == b.notNull && a.typ.name == b.typ.name && a.typ.length == b.typ.length && a.typ.scale == b.typ.scale &&
+		a.typ.unsigned == b.typ.unsigned && a.typ.charset == b.typ.charset && a.typ.collation == b.typ.collation &&
+		slices.Equal(a.typ.values, b.typ.values)
+}
+
+func sameIndex(a, b *schemaIndex) bool {
+	return a.unique == b.unique && slices.EqualFunc(a.columns, b.columns, strings.EqualFold)
+}
+
+func sameConstraint(a, b *schemaConstraint) bool {
+	return a.kind == b.kind && slices.EqualFunc(a.columns, b.columns, strings.EqualFold)
+}
@@ -0,0 +1,182 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff_MySQL(t *testing.T) {
+	old := `
+CREATE TABLE users (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  name varchar(100) NOT NULL,
+  email varchar(255) NOT NULL,
+  age int DEFAULT NULL,
+  team_id bigint unsigned DEFAULT NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY idx_email (email),
+  KEY idx_name (name),
+  CONSTRAINT fk_team FOREIGN KEY (team_id) REFERENCES teams (id),
+  CONSTRAINT chk_age CHECK (age >= 0)
+);
+CREATE TABLE teams (id bigint unsigned NOT NULL, PRIMARY KEY (id));
+CREATE TABLE logs (id bigint NOT NULL, message text);
+CREATE TABLE sessions (id bigint NOT NULL, token varchar(64) NOT NULL);`
+
+	new := `
+CREATE TABLE teams (id bigint unsigned NOT NULL, PRIMARY KEY (id));
+-- breaql:ignore mysql/rename-table reason="done in two steps"
+CREATE TABLE user_sessions (id bigint NOT NULL, token varchar(64) NOT NULL);
+CREATE TABLE users (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  full_name varchar(100) NOT NULL,
+  email varchar(100) NOT NULL,
+  team_id bigint unsigned DEFAULT NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uniq_email (email),
+  KEY idx_name (email)
+);`
+
+	oldSchema, err := breaql.LoadMySQLSchema(old)
+	require.NoError(t, err)
+	newSchema, err := breaql.LoadMySQLSchema(new)
+	require.NoError(t, err)
+
+	got, err := breaql.Diff(oldSchema, newSchema)
+	require.NoError(t, err)
+
+	want := []string{
+		"mysql/rename-column users: ALTER TABLE users RENAME COLUMN name TO full_name;",
+		"mysql/narrow-column-type users: ALTER TABLE users MODIFY COLUMN email VARCHAR(100) NOT NULL;",
+		"mysql/drop-column users: ALTER TABLE users DROP COLUMN age;",
+		"mysql/rename-index users: ALTER TABLE users RENAME INDEX idx_email TO uniq_email;",
+		"mysql/drop-index users: ALTER TABLE users DROP INDEX idx_name;",
+		"mysql/drop-foreign-key users: ALTER TABLE users DROP FOREIGN KEY fk_team;",
+		"mysql/drop-check-constraint users: ALTER TABLE users DROP CHECK chk_age;",
+		"mysql/drop-table logs: DROP TABLE logs;",
+	}
+	if diff := cmp.Diff(want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+		return f.RuleID + " " + f.Object + ": " + f.Statement
+	})); diff != "" {
+		t.Errorf("Diff() findings mismatch (-want +got):\n%s", diff)
+	}
+
+	// The findings point to the CREATE TABLE statement in the new snapshot.
+	assert.Equal(t, breaql.Position{Offset: 209, Line: 5, Column: 1}, got.Findings[0].Position)
+	assert.Equal(t, "index idx_name of table users is redefined", got.Findings[4].Message)
+	assert.Equal(t, breaql.Position{}, got.Findings[7].Position)
+
+	// Comments before the CREATE TABLE statement suppress the findings.
+	assert.Equal(t, []string{"mysql/rename-table sessions"}, lo.Map(got.Suppressed, func(f breaql.Finding, _ int) string {
+		return f.RuleID + " " + f.Object
+	}))
+
+	got, err = breaql.Diff(oldSchema, oldSchema)
+	require.NoError(t, err)
+	assert.Empty(t, got.Findings)
+}
+
+func TestDiff_PostgreSQL(t *testing.T) {
+	old := `
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name character varying(50) NOT NULL,
+    email text,
+    score integer,
+    team_id integer REFERENCES public.teams (id),
+    CONSTRAINT users_score_check CHECK (score >= 0)
+);
+CREATE TABLE public.legacy (id integer);
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_email_key UNIQUE (email);
+CREATE INDEX users_name_idx ON public.users USING btree (name);
+CREATE INDEX users_score_idx ON public.users USING btree (score);`
+
+	new := `
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name character varying(100) NOT NULL,
+    email text,
+    score bigint,
+    team_id integer
+);
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_pk PRIMARY KEY (id);
+ALTER TABLE ONLY public.users ADD CONSTRAINT users_email_key UNIQUE (email);
+CREATE INDEX idx_users_name ON public.users USING btree (name);`
+
+	oldSchema, err := breaql.LoadPostgreSQLSchema(old)
+	require.NoError(t, err)
+	newSchema, err := breaql.LoadPostgreSQLSchema(new)
+	require.NoError(t, err)
+
+	got, err := breaql.Diff(oldSchema, newSchema)
+	require.NoError(t, err)
+
+	want := []string{
+		"pg/alter-column-type-rewrite public.users: ALTER TABLE public.users ALTER COLUMN score TYPE int8;",
+		"pg/rename-index public.users_name_idx: ALTER INDEX public.users_name_idx RENAME TO idx_users_name;",
+		"pg/drop-index public.users_score_idx: DROP INDEX public.users_score_idx;",
+		"pg/drop-constraint public.users: ALTER TABLE public.users DROP CONSTRAINT users_team_id_fkey;",
+		"pg/drop-constraint public.users: ALTER TABLE public.users DROP CONSTRAINT users_score_check;",
+		"pg/rename-constraint public.users: ALTER TABLE public.users RENAME CONSTRAINT users_pkey TO users_pk;",
+		"pg/drop-table public.legacy: DROP TABLE public.legacy;",
+	}
+	if diff := cmp.Diff(want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+		return f.RuleID + " " + f.Object + ": " + f.Statement
+	}), cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("Diff() findings mismatch (-want +got):\n%s", diff)
+	}
+
+	mysqlSchema, err := breaql.LoadMySQLSchema("CREATE TABLE users (id int);")
+	require.NoError(t, err)
+	_, err = breaql.Diff(oldSchema, mysqlSchema)
+	assert.Error(t, err)
+}
+
+func TestDiff_SetNotNull(t *testing.T) {
+	tests := []struct {
+		name string
+		load func(sql string) (*breaql.Schema, error)
+		old  string
+		new  string
+		want []string
+	}{
+		{
+			name: "MySQL",
+			load: breaql.LoadMySQLSchema,
+			old:  "CREATE TABLE users (id bigint NOT NULL, nickname varchar(50) DEFAULT NULL, bio text NOT NULL);",
+			new:  "CREATE TABLE users (id bigint NOT NULL, nickname varchar(50) NOT NULL, bio text);",
+			want: []string{"mysql/column-set-not-null users.nickname: ALTER TABLE users MODIFY COLUMN nickname VARCHAR(50) NOT NULL;"},
+		},
+		{
+			name: "PostgreSQL",
+			load: breaql.LoadPostgreSQLSchema,
+			old:  "CREATE TABLE users (id bigint NOT NULL, nickname varchar(50), bio text NOT NULL);",
+			new:  "CREATE TABLE users (id bigint NOT NULL, nickname varchar(50) NOT NULL, bio text);",
+			want: []string{"pg/column-set-not-null users.nickname: ALTER TABLE users ALTER COLUMN nickname SET NOT NULL;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldSchema, err := tt.load(tt.old)
+			require.NoError(t, err)
+			newSchema, err := tt.load(tt.new)
+			require.NoError(t, err)
+
+			got, err := breaql.Diff(oldSchema, newSchema)
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+				return f.RuleID + " " + f.Object + "." + f.Column + ": " + f.Statement
+			}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Diff() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
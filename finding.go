@@ -0,0 +1,233 @@
+package breaql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Finding is a single breaking change detected in a statement.
+type Finding struct {
+	RuleID     string     `json:"rule_id"`
+	Severity   Severity   `json:"severity"`
+	Category   Category   `json:"category"` // category of the rule
+	ObjectKind ObjectKind `json:"object_kind"`
+	Object     string     `json:"object"`               // qualified name of the affected object
+	Column     string     `json:"column,omitempty"`     // affected column, if any
+	Constraint string     `json:"constraint,omitempty"` // affected constraint, foreign key or index of the table, if any
+	NewName    string     `json:"new_name,omitempty"`   // new name of the renamed object, column or constraint, if any
+	Partitions []string   `json:"partitions,omitempty"` // affected partitions of the table, if any
+	Lock       string     `json:"lock,omitempty"`       // lock taken on the object, for operational risks
+	Algorithm  string     `json:"algorithm,omitempty"`  // MySQL online DDL algorithm, for operational risks
+	Statement  string     `json:"statement"`
+	Position   Position   `json:"position"` // start of the statement in the input
+	End        Position   `json:"end"`      // end of the statement in the input (exclusive)
+	Message    string     `json:"message"`  // human-readable explanation
+
+	Suppression *Suppression `json:"suppression,omitempty"` // set if an inline comment accepts the finding
+}
+
+// Severity is the seriousness of a finding.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota + 1
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+// ParseSeverity parses the name of a severity such as "error".
+func ParseSeverity(s string) (Severity, error) {
+	for sev, name := range severityNames {
+		if strings.EqualFold(s, name) {
+			return sev, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity: %q", s)
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	if _, ok := severityNames[s]; !ok {
+		return nil, fmt.Errorf("invalid severity: %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(text []byte) error {
+	sev, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = sev
+	return nil
+}
+
+// ObjectKind is the kind of database object affected by a finding.
+type ObjectKind string
+
+const (
+	ObjectKindDatabase  ObjectKind = "database"
+	ObjectKindSchema    ObjectKind = "schema"
+	ObjectKindTable     ObjectKind = "table"
+	ObjectKindView      ObjectKind = "view"
+	ObjectKindIndex     ObjectKind = "index"
+	ObjectKindSequence  ObjectKind = "sequence"
+	ObjectKindType      ObjectKind = "type" // types and domains
+	ObjectKindTrigger   ObjectKind = "trigger"
+	ObjectKindPolicy    ObjectKind = "policy"
+	ObjectKindRoutine   ObjectKind = "routine" // stored procedures, functions and events
+	ObjectKindExtension ObjectKind = "extension"
+)
+
+// objectKinds lists the kinds of objects in the order they are reported.
+var objectKinds = []ObjectKind{
+	ObjectKindTable, ObjectKindView, ObjectKindIndex, ObjectKindSequence, ObjectKindType, ObjectKindTrigger, ObjectKindPolicy,
+	ObjectKindRoutine, ObjectKindExtension, ObjectKindSchema, ObjectKindDatabase,
+}
+
+// label returns the capitalized name of the kind.
+func (k ObjectKind) label() string {
+	return strings.ToUpper(string(k[:1])) + string(k[1:])
+}
+
+// Position is a location in the analyzed SQL text.
+type Position struct {
+	Offset int `json:"offset"` // 0-based, in bytes
+	Line   int `json:"line"`   // 1-based
+	Column int `json:"column"` // 1-based, in characters
+}
+
+func positionOf(src string, offset int) Position {
+	offset = min(max(offset, 0), len(src))
+	before := src[:offset]
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return Position{
+		Offset: offset,
+		Line:   strings.Count(before, "\n") + 1,
+		Column: utf8.RuneCountInString(before[lineStart:]) + 1,
+	}
+}
+
+// statement is a top-level statement located in the input.
+type statement struct {
+	text  string   // trimmed text as reported to users
+	start Position // position of the first token after leading comments
+	end   Position // position just after the last token
+
+	suppressions []Suppression // breaql:ignore directives attached to the statement
+}
+
+// newStatement locates raw, which begins at offset in src.
+func newStatement(src, raw string, offset int) statement {
+	n, leading := scanTrivia(raw)
+	end := offset + len(strings.TrimRightFunc(raw, unicode.IsSpace))
+	stmt := statement{
+		text:  strings.TrimSpace(raw),
+		start: positionOf(src, offset+n),
+		end:   positionOf(src, end),
+	}
+
+	// Comments on their own lines before the statement. Those following the previous statement
+	// on the same line belong to that statement.
+	for _, c := range leading {
+		lineStart := strings.LastIndexByte(src[:offset+c.offset], '\n') + 1
+		if strings.TrimSpace(src[lineStart:offset+c.offset]) == "" {
+			stmt.suppressions = append(stmt.suppressions, parseSuppressions(c.body)...)
+		}
+	}
+	// Comments following the statement on the same line.
+	rest := src[min(end, len(src)):]
+	if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+		rest = rest[:nl]
+	}
+	_, trailing := scanTrivia(strings.TrimLeft(rest, "; \t"))
+	for _, c := range trailing {
+		stmt.suppressions = append(stmt.suppressions, parseSuppressions(c.body)...)
+	}
+
+	return stmt
+}
+
+func (s statement) finding(ruleID string, kind ObjectKind, object, message string) Finding {
+	f := Finding{
+		RuleID:     ruleID,
+		Severity:   ruleSeverity(ruleID),
+		Category:   ruleCategory(ruleID),
+		ObjectKind: kind,
+		Object:     object,
+		Statement:  s.text,
+		Position:   s.start,
+		End:        s.end,
+		Message:    message,
+	}
+	for _, sup := range s.suppressions {
+		if sup.covers(ruleID) {
+			f.Suppression = &sup
+			break
+		}
+	}
+	return f
+}
+
+// comment is a SQL comment found in the input.
+type comment struct {
+	offset int    // offset of the comment in the scanned text
+	body   string // text without the delimiters
+}
+
+// scanTrivia returns the length of leading whitespace and comments in s, and the comments.
+func scanTrivia(s string) (int, []comment) {
+	var comments []comment
+	i := 0
+	for i < len(s) {
+		switch {
+		case s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r':
+			i++
+		case strings.HasPrefix(s[i:], "--") || s[i] == '#':
+			bodyStart := i + 1
+			if s[i] == '-' {
+				bodyStart++
+			}
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return len(s), append(comments, comment{offset: i, body: s[bodyStart:]})
+			}
+			comments = append(comments, comment{offset: i, body: s[bodyStart : i+end]})
+			i += end + 1
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return len(s), append(comments, comment{offset: i, body: s[i+2:]})
+			}
+			comments = append(comments, comment{offset: i, body: s[i+2 : i+2+end]})
+			i += end + 4
+		default:
+			return i, comments
+		}
+	}
+	return i, comments
+}
+
+// skipTrivia returns the length of leading whitespace and comments in s.
+func skipTrivia(s string) int {
+	n, _ := scanTrivia(s)
+	return n
+}
@@ -0,0 +1,65 @@
+package breaql
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONVersion is the version of the JSON document written by WriteJSON.
+// It is incremented whenever an existing field changes its meaning or is removed.
+const JSONVersion = 1
+
+// JSONReport is the JSON document written by WriteJSON.
+type JSONReport struct {
+	Version int        `json:"version"` // always JSONVersion
+	Driver  string     `json:"driver"`  // "mysql" or "pg"
+	Files   []JSONFile `json:"files"`   // in the order of the inputs
+	Summary Summary    `json:"summary"` // counts over all the files
+}
+
+// JSONFile is the result for a single input in a JSONReport.
+type JSONFile struct {
+	Path       string       `json:"path"`       // "-" for the standard input
+	Findings   []Finding    `json:"findings"`   // in the requested order
+	Suppressed []Finding    `json:"suppressed"` // findings accepted by breaql:ignore comments
+	Objects    []JSONObject `json:"objects"`    // affected objects in the order of their first finding
+}
+
+// JSONObject is an object affected by breaking statements.
+type JSONObject struct {
+	Kind       ObjectKind `json:"kind"`
+	Name       string     `json:"name"`
+	Statements []string   `json:"statements"` // in source order
+}
+
+// WriteJSON writes the report to w as an indented JSONReport.
+func WriteJSON(w io.Writer, r Report) error {
+	doc := JSONReport{
+		Version: JSONVersion,
+		Driver:  r.Driver,
+		Files:   make([]JSONFile, 0, len(r.Files)),
+		Summary: r.Summary(),
+	}
+	for _, file := range r.Files {
+		doc.Files = append(doc.Files, JSONFile{
+			Path:       file.Path,
+			Findings:   append([]Finding{}, file.Changes.Findings...),
+			Suppressed: append([]Finding{}, file.Changes.Suppressed...),
+			Objects:    jsonObjects(file.Changes),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func jsonObjects(bc BreakingChanges) []JSONObject {
+	objects := []JSONObject{}
+	for _, kind := range objectKinds {
+		for _, name := range bc.Objects(kind) {
+			objects = append(objects, JSONObject{Kind: kind, Name: name, Statements: bc.Statements(kind, name)})
+		}
+	}
+	return objects
+}
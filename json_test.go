@@ -0,0 +1,53 @@
+package breaql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSON(t *testing.T) {
+	changes, err := breaql.RunMySQL("ALTER TABLE users DROP COLUMN age;\nDROP DATABASE foo;")
+	require.NoError(t, err)
+
+	report := breaql.Report{
+		Driver: "mysql",
+		Files:  []breaql.FileReport{{Path: "migrations/001.sql", Changes: changes}},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, breaql.WriteJSON(buf, report))
+
+	var got breaql.JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	want := breaql.JSONReport{
+		Version: breaql.JSONVersion,
+		Driver:  "mysql",
+		Files: []breaql.JSONFile{
+			{
+				Path:       "migrations/001.sql",
+				Findings:   changes.Findings,
+				Suppressed: []breaql.Finding{},
+				Objects: []breaql.JSONObject{
+					{Kind: breaql.ObjectKindTable, Name: "users", Statements: []string{"ALTER TABLE users DROP COLUMN age;"}},
+					{Kind: breaql.ObjectKindDatabase, Name: "foo", Statements: []string{"DROP DATABASE foo;"}},
+				},
+			},
+		},
+		Summary: breaql.Summary{
+			Files:      1,
+			Findings:   2,
+			BySeverity: map[breaql.Severity]int{breaql.SeverityError: 2},
+			ByRule:     map[string]int{"mysql/drop-column": 1, "mysql/drop-database": 1},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("WriteJSON() mismatch (-want +got):\n%s", diff)
+	}
+	assert.Contains(t, buf.String(), `"severity": "error"`)
+}
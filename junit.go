@@ -0,0 +1,93 @@
+package breaql
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Skipped   int             `xml:"skipped,attr"`
+		TestCases []junitTestCase `xml:"testcase"`
+	}
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	}
+	junitSkipped struct {
+		Message string `xml:"message,attr"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// WriteJUnit writes the report to w as JUnit XML, where each finding is a failed test case
+// and each suppressed finding is a skipped one.
+// A file without findings yields a single passed test case so that it still shows up in the results.
+func WriteJUnit(w io.Writer, r Report) error {
+	suites := junitTestSuites{Name: "breaql"}
+	for _, file := range r.Files {
+		suite := junitTestSuite{Name: file.Path}
+		for _, f := range file.Changes.Findings {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      fmt.Sprintf("%s %s", f.RuleID, f.Object),
+				ClassName: file.Path,
+				Failure: &junitFailure{
+					Message: f.Message,
+					Type:    f.RuleID,
+					Text:    fmt.Sprintf("%s:%d:%d [%s] %s\n%s", file.Path, f.Position.Line, f.Position.Column, f.Severity, f.Message, f.Statement),
+				},
+			})
+			suite.Failures++
+		}
+		for _, f := range file.Changes.Suppressed {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      fmt.Sprintf("%s %s", f.RuleID, f.Object),
+				ClassName: file.Path,
+				Skipped:   &junitSkipped{Message: "suppressed by breaql:ignore: " + f.Suppression.Reason},
+			})
+			suite.Skipped++
+		}
+		if len(suite.TestCases) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: "no breaking changes", ClassName: file.Path})
+		}
+		suite.Tests = len(suite.TestCases)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	return writeXML(w, suites)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -1,11 +1,15 @@
 package breaql
 
 import (
+	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/pingcap/tidb/pkg/parser"
 	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/model"
 	"github.com/samber/lo"
 
 	// Importing the following parser driver causes a build error.
@@ -16,56 +20,515 @@ import (
 
 // RunMySQL parses the given (possibly composite) DDL statements and returns the breaking ones.
 func RunMySQL(sql string) (BreakingChanges, error) {
-	p := parser.New()
-	stmtNodes, _, err := p.Parse(sql, "", "")
+	return RunMySQLWithOptions(sql, Options{})
+}
+
+// RunMySQLWithOptions is like RunMySQL but customizes the analysis with opts.
+func RunMySQLWithOptions(sql string, opts Options) (BreakingChanges, error) {
+	version, err := parseMySQLVersion(opts.MySQLVersion)
+	if err != nil {
+		return BreakingChanges{}, err
+	}
+	stmtNodes, stmts, drops, err := parseMySQL(sql)
 	if err != nil {
-		return BreakingChanges{}, &ParseError{original: err, Message: err.Error(), funcName: "parser.Parse"}
+		return BreakingChanges{}, err
 	}
 
-	changes := NewBreakingChanges()
+	a := newAnalysis(opts)
 
-	for _, stmtNode := range stmtNodes {
-		stmtText := strings.TrimSpace(stmtNode.Text())
-		slog.Debug("processing stmt", slog.String("stmt", stmtText))
+	// The statements the parser does not support are reported in the order of the input as well.
+	addDrops := func(before int) {
+		for len(drops) > 0 && drops[0].stmt.start.Offset < before {
+			slog.Debug("processing stmt", slog.String("stmt", drops[0].stmt.text))
+			a.add(drops[0].finding())
+			drops = drops[1:]
+		}
+	}
 
-		switch stmt := stmtNode.(type) {
+	for i, stmtNode := range stmtNodes {
+		stmt := stmts[i]
+		addDrops(stmt.start.Offset)
+		slog.Debug("processing stmt", slog.String("stmt", stmt.text))
+
+		switch n := stmtNode.(type) {
 		case *ast.DropDatabaseStmt:
-			changes.Databases.add(stmt.Name.String(), stmtText)
+			name := n.Name.String()
+			a.add(stmt.finding("mysql/drop-database", ObjectKindDatabase, name,
+				fmt.Sprintf("database %s is dropped along with all of its tables", name)))
 
 		case *ast.DropTableStmt:
-			lo.ForEach(stmt.Tables, func(stmt *ast.TableName, _ int) { changes.Tables.add(stmt.Name.String(), stmtText) })
+			for _, tn := range n.Tables {
+				name := mysqlTableName(tn)
+				if n.IsView {
+					a.add(stmt.finding("mysql/drop-view", ObjectKindView, name,
+						fmt.Sprintf("view %s is dropped", name)))
+					continue
+				}
+				a.add(stmt.finding("mysql/drop-table", ObjectKindTable, name,
+					fmt.Sprintf("table %s is dropped", name)))
+			}
+
+		case *ast.DropSequenceStmt:
+			for _, tn := range n.Sequences {
+				name := mysqlTableName(tn)
+				a.add(stmt.finding("mysql/drop-sequence", ObjectKindSequence, name,
+					fmt.Sprintf("sequence %s is dropped", name)))
+			}
+
+		case *ast.DropProcedureStmt:
+			name := mysqlTableName(n.ProcedureName)
+			a.add(stmt.finding("mysql/drop-procedure", ObjectKindRoutine, name,
+				fmt.Sprintf("procedure %s is dropped", name)))
+
+		case *ast.CreateViewStmt:
+			if n.OrReplace {
+				for _, f := range replaceViewFindings(stmt, a.schema.view(mysqlTableName(n.ViewName)), n) {
+					a.add(f)
+				}
+			}
+
+		case *ast.CreateIndexStmt:
+			if n.KeyType == ast.IndexKeyTypeUnique {
+				table := mysqlTableName(n.Table)
+				f := stmt.finding("mysql/add-unique-constraint", ObjectKindTable, table,
+					fmt.Sprintf("unique index %s is created on table %s, which rejects the writes of duplicate values and fails if the existing rows have duplicates", n.IndexName, table))
+				f.Constraint = n.IndexName
+				a.add(f)
+			}
+
+		case *ast.DeleteStmt, *ast.UpdateStmt:
+			for _, f := range mysqlDMLFindings(stmt, stmtNode) {
+				a.add(f)
+			}
 
 		case *ast.TruncateTableStmt:
-			changes.Tables.add(stmt.Table.Name.String(), stmtText)
+			table := mysqlTableName(n.Table)
+			a.add(stmt.finding("mysql/truncate-table", ObjectKindTable, table,
+				fmt.Sprintf("all rows in table %s are deleted", table)))
 
 		case *ast.RenameTableStmt:
-			lo.ForEach(stmt.TableToTables, func(ttt *ast.TableToTable, _ int) { changes.Tables.add(ttt.OldTable.Name.String(), stmtText) })
+			for _, ttt := range n.TableToTables {
+				a.add(mysqlRenameTableFinding(stmt, mysqlTableName(ttt.OldTable), mysqlTableName(ttt.NewTable)))
+			}
 
 		case *ast.AlterTableStmt:
-			for _, spec := range stmt.Specs {
-				if isBreakingAlterTableSpec(spec) {
-					changes.Tables.add(stmt.Table.Name.String(), stmtText)
-					break
+			table := mysqlTableName(n.Table)
+			t := a.schema.table(table)
+			for _, spec := range n.Specs {
+				for _, f := range alterTableSpecFindings(stmt, table, t, spec) {
+					a.add(f)
 				}
 			}
 		}
 
+		if opts.OperationalRisks {
+			for _, f := range mysqlOnlineDDLFindings(stmt, a.schema, version, stmtNode) {
+				a.add(f)
+			}
+			for _, f := range mysqlUnbatchedDMLFindings(stmt, stmtNode) {
+				a.add(f)
+			}
+		}
+		applyMySQLStmt(a.schema, stmt, stmtNode)
+	}
+	addDrops(len(sql) + 1)
+
+	return a.changes, nil
+}
+
+// parseMySQL parses the given statements and locates them in sql.
+// The statements the TiDB parser does not support, i.e. dropping triggers, stored functions and events,
+// are blanked out before parsing and returned separately.
+func parseMySQL(sql string) ([]ast.StmtNode, []statement, []mysqlDrop, error) {
+	parsed, drops := extractMySQLDrops(sql)
+
+	p := parser.New()
+	stmtNodes, _, err := p.Parse(parsed, "", "")
+	if err != nil {
+		return nil, nil, nil, &ParseError{original: err, Message: err.Error(), funcName: "parser.Parse"}
+	}
+
+	stmts := make([]statement, 0, len(stmtNodes))
+
+	// The parser does not record the offsets of top-level statements, so we find them by their text.
+	cursor := 0
+	for _, stmtNode := range stmtNodes {
+		raw := stmtNode.Text()
+		offset := cursor
+		if i := strings.Index(parsed[cursor:], raw); i >= 0 {
+			offset += i
+			cursor = offset + len(raw)
+		}
+		stmts = append(stmts, newStatement(sql, raw, offset))
+	}
+	return stmtNodes, stmts, drops, nil
+}
+
+// mysqlDrop is a statement dropping an object that the TiDB parser does not support.
+type mysqlDrop struct {
+	stmt statement
+	kind string // "TRIGGER", "FUNCTION" or "EVENT"
+	name string
+}
+
+var mysqlUnsupportedDrop = regexp.MustCompile(`(?i)^DROP\s+(TRIGGER|FUNCTION|EVENT)\s+(?:IF\s+EXISTS\s+)?([^\s;]+)`)
+
+// extractMySQLDrops returns sql with the unsupported statements replaced by spaces, which keeps the offsets
+// of the others, and the unsupported statements.
+func extractMySQLDrops(sql string) (string, []mysqlDrop) {
+	var drops []mysqlDrop
+	parsed := []byte(sql)
+	for start := 0; start < len(sql); {
+		end := start + mysqlStatementEnd(sql[start:])
+		m := mysqlUnsupportedDrop.FindStringSubmatch(sql[start+skipTrivia(sql[start:end]) : end])
+		if m != nil {
+			drops = append(drops, mysqlDrop{
+				stmt: newStatement(sql, sql[start:end], start),
+				kind: strings.ToUpper(m[1]),
+				name: strings.ReplaceAll(m[2], "`", ""),
+			})
+			// The leading comments are blanked as well so that they are not attached to the next statement.
+			for i := start; i < end; i++ {
+				if parsed[i] != '\n' {
+					parsed[i] = ' '
+				}
+			}
+		}
+		start = end
+	}
+	return string(parsed), drops
+}
+
+// mysqlStatementEnd returns the offset just after the semicolon terminating the first statement in s,
+// skipping quoted strings and comments, or len(s) if the statement is not terminated.
+func mysqlStatementEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == ';':
+			return i + 1
+		case s[i] == '\'' || s[i] == '"' || s[i] == '`':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case strings.HasPrefix(s[i:], "--") || s[i] == '#':
+			nl := strings.IndexByte(s[i:], '\n')
+			if nl < 0 {
+				return len(s)
+			}
+			i += nl
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 3
+		}
+	}
+	return len(s)
+}
+
+func (d mysqlDrop) finding() Finding {
+	switch d.kind {
+	case "TRIGGER":
+		return d.stmt.finding("mysql/drop-trigger", ObjectKindTrigger, d.name,
+			fmt.Sprintf("trigger %s is dropped", d.name))
+	case "FUNCTION":
+		return d.stmt.finding("mysql/drop-function", ObjectKindRoutine, d.name,
+			fmt.Sprintf("function %s is dropped", d.name))
+	default:
+		return d.stmt.finding("mysql/drop-event", ObjectKindRoutine, d.name,
+			fmt.Sprintf("event %s is dropped", d.name))
+	}
+}
+
+// replaceViewFindings returns the findings for the view redefined by CREATE OR REPLACE VIEW.
+// old is the view in the baseline schema, or nil if unknown.
+func replaceViewFindings(stmt statement, old *schemaView, n *ast.CreateViewStmt) []Finding {
+	view := mysqlTableName(n.ViewName)
+	columns := mysqlViewColumns(n)
+	if old == nil || old.columns == nil || columns == nil {
+		// Note: False positives are accepted here as the old columns are unknown without a baseline.
+		return []Finding{stmt.finding("mysql/replace-view", ObjectKindView, view,
+			fmt.Sprintf("view %s is redefined, which may change its columns", view))}
+	}
+
+	var findings []Finding
+	for _, column := range old.columns {
+		if !slices.ContainsFunc(columns, func(c string) bool { return strings.EqualFold(c, column) }) {
+			f := stmt.finding("mysql/change-view-columns", ObjectKindView, view,
+				fmt.Sprintf("column %s is removed from view %s", column, view))
+			f.Column = column
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// mysqlViewColumns returns the names of the columns of the view, or nil if any of them is unknown.
+func mysqlViewColumns(n *ast.CreateViewStmt) []string {
+	if len(n.Cols) > 0 {
+		return lo.Map(n.Cols, func(col model.CIStr, _ int) string { return col.O })
 	}
 
-	return changes, nil
+	sel, _ := n.Select.(*ast.SelectStmt)
+	if setOpr, ok := n.Select.(*ast.SetOprStmt); ok && setOpr.SelectList != nil && len(setOpr.SelectList.Selects) > 0 {
+		// The columns of a UNION are named after its first SELECT.
+		sel, _ = setOpr.SelectList.Selects[0].(*ast.SelectStmt)
+	}
+	if sel == nil || sel.Fields == nil {
+		return nil
+	}
+
+	columns := make([]string, 0, len(sel.Fields.Fields))
+	for _, field := range sel.Fields.Fields {
+		if field.AsName.O != "" {
+			columns = append(columns, field.AsName.O)
+			continue
+		}
+		// Wildcards and expressions without an alias are named by the server.
+		col, ok := field.Expr.(*ast.ColumnNameExpr)
+		if !ok {
+			return nil
+		}
+		columns = append(columns, col.Name.Name.O)
+	}
+	return columns
 }
 
-func isBreakingAlterTableSpec(spec *ast.AlterTableSpec) bool {
+// alterTableSpecFindings returns the findings for the given spec if it is breaking.
+// t is the table in the baseline schema, or nil if unknown.
+func alterTableSpecFindings(stmt statement, table string, t *schemaTable, spec *ast.AlterTableSpec) []Finding {
 	switch spec.Tp {
-	case ast.AlterTableDropColumn, ast.AlterTableDropIndex,
-		ast.AlterTableDropForeignKey, ast.AlterTableDropPrimaryKey:
-		return true
+	case ast.AlterTableAddColumns:
+		var findings []Finding
+		for _, def := range spec.NewColumns {
+			if mysqlRequiresValue(def) {
+				f := stmt.finding("mysql/add-not-null-column", ObjectKindTable, table,
+					fmt.Sprintf("column %s is added to table %s as NOT NULL without a default, which rejects writers omitting it", def.Name.Name, table))
+				f.Column = def.Name.Name.String()
+				findings = append(findings, f)
+			}
+		}
+		return findings
+
+	case ast.AlterTableAlterColumn:
+		column := spec.NewColumns[0].Name.Name.String()
+		if len(spec.NewColumns[0].Options) > 0 {
+			return nil // SET DEFAULT
+		}
+		message := fmt.Sprintf("default of column %s of table %s is dropped, which rejects writers omitting it if the column is NOT NULL", column, table)
+		if old := t.column(column); old != nil {
+			if !old.notNull {
+				return nil
+			}
+			message = fmt.Sprintf("default of NOT NULL column %s of table %s is dropped, which rejects writers omitting it", column, table)
+		}
+		f := stmt.finding("mysql/drop-column-default", ObjectKindTable, table, message)
+		f.Column = column
+		return []Finding{f}
 
-	case ast.AlterTableModifyColumn:
-		// Note: False positives are accepted here as we cannot obtain the old column type.
-		return true
+	case ast.AlterTableAddConstraint:
+		if f, ok := mysqlAddConstraintFinding(stmt, table, spec.Constraint); ok {
+			return []Finding{f}
+		}
+		return nil
+
+	case ast.AlterTableDropColumn:
+		column := spec.OldColumnName.Name.String()
+		f := stmt.finding("mysql/drop-column", ObjectKindTable, table,
+			fmt.Sprintf("column %s is dropped from table %s", column, table))
+		f.Column = column
+		return []Finding{f}
+
+	case ast.AlterTableDropIndex:
+		f := stmt.finding("mysql/drop-index", ObjectKindTable, table,
+			fmt.Sprintf("index %s is dropped from table %s", spec.Name, table))
+		f.Constraint = spec.Name
+		return []Finding{f}
+
+	case ast.AlterTableDropForeignKey:
+		f := stmt.finding("mysql/drop-foreign-key", ObjectKindTable, table,
+			fmt.Sprintf("foreign key %s is dropped from table %s", spec.Name, table))
+		f.Constraint = spec.Name
+		return []Finding{f}
+
+	case ast.AlterTableDropCheck:
+		// DROP CONSTRAINT is parsed as DROP CHECK as well.
+		name := spec.Constraint.Name
+		f := stmt.finding("mysql/drop-check-constraint", ObjectKindTable, table,
+			fmt.Sprintf("check constraint %s is dropped from table %s", name, table))
+		f.Constraint = name
+		return []Finding{f}
+
+	case ast.AlterTableDropPrimaryKey:
+		f := stmt.finding("mysql/drop-primary-key", ObjectKindTable, table,
+			fmt.Sprintf("primary key is dropped from table %s", table))
+		f.Constraint = "PRIMARY"
+		return []Finding{f}
+
+	case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+		var findings []Finding
+		column, newName := spec.NewColumns[0].Name.Name.String(), spec.NewColumns[0].Name.Name.String()
+		if spec.Tp == ast.AlterTableChangeColumn {
+			column = spec.OldColumnName.Name.String()
+			if !strings.EqualFold(column, newName) {
+				findings = append(findings, mysqlRenameColumnFinding(stmt, table, column, newName))
+			}
+		}
+		if old := t.column(column); old != nil {
+			return append(findings, mysqlColumnFindings(stmt, table, t, t, old, mysqlSchemaColumn(spec.NewColumns[0]))...)
+		}
+		// Note: False positives are accepted here as the old column type is unknown without a baseline.
+		message := fmt.Sprintf("column %s of table %s is redefined, which may narrow its type", column, table)
+		if mysqlSchemaColumn(spec.NewColumns[0]).notNull {
+			message = fmt.Sprintf("column %s of table %s is redefined as NOT NULL, which may narrow its type or fail on NULL values", column, table)
+		}
+		f := stmt.finding("mysql/modify-column", ObjectKindTable, table, message)
+		f.Column = column
+		return append(findings, f)
+
+	case ast.AlterTableRenameColumn:
+		return []Finding{mysqlRenameColumnFinding(stmt, table, spec.OldColumnName.Name.String(), spec.NewColumnName.Name.String())}
+
+	case ast.AlterTableRenameTable:
+		return []Finding{mysqlRenameTableFinding(stmt, table, mysqlTableName(spec.NewTable))}
+
+	case ast.AlterTableRenameIndex:
+		f := stmt.finding("mysql/rename-index", ObjectKindTable, table,
+			fmt.Sprintf("index %s of table %s is renamed to %s", spec.FromKey, table, spec.ToKey))
+		f.Constraint = spec.FromKey.String()
+		f.NewName = spec.ToKey.String()
+		return []Finding{f}
+
+	case ast.AlterTableDropPartition:
+		f := stmt.finding("mysql/drop-partition", ObjectKindTable, table,
+			fmt.Sprintf("rows in %s of table %s are deleted by dropping the partitions", partitionList(mysqlPartitions(spec)), table))
+		f.Partitions = mysqlPartitions(spec)
+		return []Finding{f}
+
+	case ast.AlterTableTruncatePartition:
+		if spec.OnAllPartitions {
+			return []Finding{stmt.finding("mysql/truncate-partition", ObjectKindTable, table,
+				fmt.Sprintf("all partitions of table %s are truncated", table))}
+		}
+		f := stmt.finding("mysql/truncate-partition", ObjectKindTable, table,
+			fmt.Sprintf("all rows in %s of table %s are deleted", partitionList(mysqlPartitions(spec)), table))
+		f.Partitions = mysqlPartitions(spec)
+		return []Finding{f}
+
+	case ast.AlterTableExchangePartition:
+		message := fmt.Sprintf("rows in %s of table %s are swapped with table %s",
+			partitionList(mysqlPartitions(spec)), table, mysqlTableName(spec.NewTable))
+		if !spec.WithValidation {
+			message += " without validation"
+		}
+		f := stmt.finding("mysql/exchange-partition", ObjectKindTable, table, message)
+		f.Partitions = mysqlPartitions(spec)
+		return []Finding{f}
+
+	case ast.AlterTableReorganizePartition:
+		if spec.OnAllPartitions {
+			return []Finding{stmt.finding("mysql/reorganize-partition", ObjectKindTable, table,
+				fmt.Sprintf("all partitions of table %s are reorganized", table))}
+		}
+		f := stmt.finding("mysql/reorganize-partition", ObjectKindTable, table,
+			fmt.Sprintf("rows in %s of table %s are moved into %s", partitionList(mysqlPartitions(spec)), table,
+				partitionList(lo.Map(spec.PartDefinitions, func(def *ast.PartitionDefinition, _ int) string { return def.Name.O }))))
+		f.Partitions = mysqlPartitions(spec)
+		return []Finding{f}
+
+	case ast.AlterTableCoalescePartitions:
+		return []Finding{stmt.finding("mysql/coalesce-partition", ObjectKindTable, table,
+			fmt.Sprintf("%d partitions of table %s are removed and their rows redistributed", spec.Num, table))}
+
+	case ast.AlterTableRemovePartitioning:
+		return []Finding{stmt.finding("mysql/remove-partitioning", ObjectKindTable, table,
+			fmt.Sprintf("partitioning of table %s is removed", table))}
+
+	default:
+		return nil
+	}
+}
+
+// mysqlAddConstraintFinding returns the finding on the given unique key, check constraint or foreign key added to the table.
+func mysqlAddConstraintFinding(stmt statement, table string, cons *ast.Constraint) (Finding, bool) {
+	columns := mysqlKeyColumns(cons.Keys)
+	var f Finding
+	switch cons.Tp {
+	case ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		f = stmt.finding("mysql/add-unique-constraint", ObjectKindTable, table,
+			fmt.Sprintf("%s is added to table %s, which rejects the writes of duplicate values and fails if the existing rows have duplicates",
+				constraintLabel("unique key", cons.Name, columns), table))
+
+	case ast.ConstraintCheck:
+		if !cons.Enforced {
+			return Finding{}, false
+		}
+		f = stmt.finding("mysql/add-check-constraint", ObjectKindTable, table,
+			fmt.Sprintf("%s is added to table %s, which rejects the writes violating it and fails if an existing row violates it",
+				constraintLabel("check constraint", cons.Name, nil), table))
+
+	case ast.ConstraintForeignKey:
+		referenced := mysqlTableName(cons.Refer.Table)
+		f = stmt.finding("mysql/add-foreign-key", ObjectKindTable, table,
+			fmt.Sprintf("%s is added to table %s, which rejects the writes referencing missing rows of table %s and fails if an existing row does",
+				constraintLabel("foreign key", cons.Name, columns), table, referenced))
 
 	default:
-		return false
+		return Finding{}, false
+	}
+	f.Constraint = cons.Name
+	return f, true
+}
+
+// mysqlRequiresValue returns whether the writers must set the column defined by def, i.e. it is NOT NULL without a default.
+func mysqlRequiresValue(def *ast.ColumnDef) bool {
+	notNull := false
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionNotNull, ast.ColumnOptionPrimaryKey:
+			notNull = true
+		case ast.ColumnOptionNull, ast.ColumnOptionDefaultValue, ast.ColumnOptionAutoIncrement, ast.ColumnOptionGenerated:
+			return false
+		}
+	}
+	return notNull
+}
+
+// mysqlPartitions returns the names of the partitions the spec applies to.
+func mysqlPartitions(spec *ast.AlterTableSpec) []string {
+	return lo.Map(spec.PartitionNames, func(name model.CIStr, _ int) string { return name.O })
+}
+
+// partitionList returns the names as "partition p0" or "partitions p0, p1".
+func partitionList(names []string) string {
+	if len(names) == 1 {
+		return "partition " + names[0]
+	}
+	return "partitions " + strings.Join(names, ", ")
+}
+
+func mysqlRenameTableFinding(stmt statement, table, newName string) Finding {
+	f := stmt.finding("mysql/rename-table", ObjectKindTable, table,
+		fmt.Sprintf("table %s is renamed to %s", table, newName))
+	f.NewName = newName
+	return f
+}
+
+func mysqlRenameColumnFinding(stmt statement, table, column, newName string) Finding {
+	f := stmt.finding("mysql/rename-column", ObjectKindTable, table,
+		fmt.Sprintf("column %s of table %s is renamed to %s", column, table, newName))
+	f.Column = column
+	f.NewName = newName
+	return f
+}
+
+func mysqlTableName(tn *ast.TableName) string {
+	if tn.Schema.String() != "" {
+		return tn.Schema.String() + "." + tn.Name.String()
 	}
+	return tn.Name.String()
 }
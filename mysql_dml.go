@@ -0,0 +1,161 @@
+package breaql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/opcode"
+	"github.com/samber/lo"
+)
+
+// mysqlDMLFindings returns the findings on the given DELETE or UPDATE statement if it changes every row of a table,
+// i.e. it has no WHERE clause or an always-true one and is not restricted by a join or LIMIT.
+func mysqlDMLFindings(stmt statement, stmtNode ast.StmtNode) []Finding {
+	switch n := stmtNode.(type) {
+	case *ast.DeleteStmt:
+		tables := mysqlJoinTables(n.TableRefs.TableRefs)
+		if n.IsMultiTable || n.Limit != nil || len(tables) == 0 || !mysqlAllRows(n.Where) {
+			return nil
+		}
+		table := tables[0]
+		return []Finding{stmt.finding("mysql/delete-all-rows", ObjectKindTable, table,
+			fmt.Sprintf("DELETE %s deletes the rows of table %s regardless of their values", mysqlWhereClause(n.Where), table))}
+
+	case *ast.UpdateStmt:
+		tables := mysqlJoinTables(n.TableRefs.TableRefs)
+		if n.TableRefs.TableRefs.Right != nil || n.Limit != nil || len(tables) == 0 || !mysqlAllRows(n.Where) {
+			return nil
+		}
+		table := tables[0]
+		columns := lo.Map(n.List, func(a *ast.Assignment, _ int) string { return a.Column.Name.O })
+		return []Finding{stmt.finding("mysql/update-all-rows", ObjectKindTable, table,
+			fmt.Sprintf("UPDATE %s overwrites %s of the rows of table %s regardless of their values", mysqlWhereClause(n.Where), strings.Join(columns, ", "), table))}
+	}
+	return nil
+}
+
+// mysqlUnbatchedDMLFindings returns the operational risks of the given statement if it may change many rows at once:
+// UPDATE and DELETE without LIMIT, unless the WHERE clause picks rows by key, and INSERT ... SELECT from tables without LIMIT.
+func mysqlUnbatchedDMLFindings(stmt statement, stmtNode ast.StmtNode) []Finding {
+	var tables []string
+	var message string
+	switch n := stmtNode.(type) {
+	case *ast.DeleteStmt:
+		if n.Limit != nil || mysqlPicksByKey(n.Where) {
+			return nil
+		}
+		tables = mysqlJoinTables(n.TableRefs.TableRefs)
+		if n.IsMultiTable {
+			tables = lo.Map(n.Tables.Tables, func(tn *ast.TableName, _ int) string { return mysqlTableName(tn) })
+		}
+		message = "rows of table %s are deleted by a single statement without LIMIT, which holds the row locks and delays the replicas until it finishes"
+
+	case *ast.UpdateStmt:
+		if n.Limit != nil || mysqlPicksByKey(n.Where) {
+			return nil
+		}
+		tables = lo.Slice(mysqlJoinTables(n.TableRefs.TableRefs), 0, 1)
+		message = "rows of table %s are updated by a single statement without LIMIT, which holds the row locks and delays the replicas until it finishes"
+
+	case *ast.InsertStmt:
+		sel, ok := n.Select.(*ast.SelectStmt)
+		if !ok || sel.From == nil || sel.Limit != nil {
+			return nil
+		}
+		tables = lo.Slice(mysqlJoinTables(n.Table.TableRefs), 0, 1)
+		message = "rows of table %s are backfilled by a single INSERT ... SELECT without LIMIT, which locks the rows it reads and delays the replicas until it finishes"
+		if len(n.OnDuplicate) > 0 {
+			message = "rows of table %s are backfilled by a single INSERT ... SELECT ... ON DUPLICATE KEY UPDATE without LIMIT, which overwrites the existing rows, locks the rows it reads and delays the replicas until it finishes"
+		}
+
+	default:
+		return nil
+	}
+
+	var findings []Finding
+	for _, table := range tables {
+		findings = append(findings, stmt.finding("mysql/unbatched-dml", ObjectKindTable, table, fmt.Sprintf(message, table)))
+	}
+	return findings
+}
+
+// mysqlJoinTables returns the tables in the FROM clause.
+func mysqlJoinTables(join *ast.Join) []string {
+	var tables []string
+	for _, rs := range []ast.ResultSetNode{join.Left, join.Right} {
+		switch rs := rs.(type) {
+		case *ast.TableSource:
+			if tn, ok := rs.Source.(*ast.TableName); ok {
+				tables = append(tables, mysqlTableName(tn))
+			}
+		case *ast.Join:
+			tables = append(tables, mysqlJoinTables(rs)...)
+		}
+	}
+	return tables
+}
+
+func mysqlWhereClause(where ast.ExprNode) string {
+	if where == nil {
+		return "without a WHERE clause"
+	}
+	return "with an always-true WHERE clause"
+}
+
+// mysqlAllRows returns whether the WHERE clause matches every row, i.e. it is missing or always true.
+func mysqlAllRows(where ast.ExprNode) bool {
+	return where == nil || mysqlAlwaysTrue(where)
+}
+
+// mysqlAlwaysTrue returns whether the expression is trivially true, such as TRUE, 1 or 1 = 1.
+func mysqlAlwaysTrue(expr ast.ExprNode) bool {
+	switch e := expr.(type) {
+	case *ast.ParenthesesExpr:
+		return mysqlAlwaysTrue(e.Expr)
+	case ast.ValueExpr:
+		switch v := e.GetValue().(type) {
+		case int64:
+			return v != 0
+		case uint64:
+			return v != 0
+		}
+	case *ast.BinaryOperationExpr:
+		switch e.Op {
+		case opcode.LogicOr:
+			return mysqlAlwaysTrue(e.L) || mysqlAlwaysTrue(e.R)
+		case opcode.LogicAnd:
+			return mysqlAlwaysTrue(e.L) && mysqlAlwaysTrue(e.R)
+		case opcode.EQ, opcode.NullEQ, opcode.GE, opcode.LE:
+			l, lok := e.L.(ast.ValueExpr)
+			r, rok := e.R.(ast.ValueExpr)
+			return lok && rok && l.GetValue() != nil && fmt.Sprint(l.GetValue()) == fmt.Sprint(r.GetValue())
+		}
+	}
+	return false
+}
+
+// mysqlPicksByKey returns whether the WHERE clause picks rows by values of a column, e.g. id = 1 or id IN (1, 2),
+// which is assumed to match a few rows.
+func mysqlPicksByKey(where ast.ExprNode) bool {
+	switch e := where.(type) {
+	case *ast.ParenthesesExpr:
+		return mysqlPicksByKey(e.Expr)
+	case *ast.BinaryOperationExpr:
+		switch e.Op {
+		case opcode.LogicAnd:
+			return mysqlPicksByKey(e.L) || mysqlPicksByKey(e.R)
+		case opcode.EQ:
+			_, lcol := e.L.(*ast.ColumnNameExpr)
+			_, rval := e.R.(ast.ValueExpr)
+			return lcol && rval
+		}
+	case *ast.PatternInExpr:
+		_, col := e.Expr.(*ast.ColumnNameExpr)
+		return col && !e.Not && e.Sel == nil && lo.EveryBy(e.List, func(v ast.ExprNode) bool {
+			_, ok := v.(ast.ValueExpr)
+			return ok
+		})
+	}
+	return false
+}
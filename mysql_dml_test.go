@@ -0,0 +1,105 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMySQL_DML(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs and objects of the findings, including the operational ones
+	}{
+		{name: "DeleteByKey", sql: "DELETE FROM users WHERE id = 1;"},
+		{name: "DeleteByKeys", sql: "DELETE FROM users WHERE id IN (1, 2, 3) AND deleted_at IS NOT NULL;"},
+		{name: "UpdateWithLimit", sql: "UPDATE users SET status = 'inactive' WHERE last_login_at < '2020-01-01' LIMIT 1000;"},
+		{name: "UpdateDerivedTable", sql: "UPDATE (SELECT * FROM t) AS x SET x.a = 1;"},
+		{name: "InsertValues", sql: "INSERT INTO users (id, name) VALUES (1, 'alice');"},
+		{name: "InsertSelectWithLimit", sql: "INSERT INTO archived_users SELECT * FROM users WHERE id > 1000 ORDER BY id LIMIT 1000;"},
+		{
+			name: "DeleteWithoutWhere",
+			sql:  "DELETE FROM users;",
+			want: []string{"mysql/delete-all-rows users", "mysql/unbatched-dml users"},
+		},
+		{name: "DeleteWithoutWhereWithLimit", sql: "DELETE FROM users LIMIT 100;"},
+		{name: "UpdateWithoutWhereWithLimit", sql: "UPDATE users SET score = 0 LIMIT 100;"},
+		{name: "DeleteAlwaysTrueWithLimit", sql: "DELETE FROM users WHERE 1 = 1 ORDER BY id LIMIT 100;"},
+		{
+			name: "UpdateWithoutWhere",
+			sql:  "UPDATE users SET status = 'inactive', score = 0;",
+			want: []string{"mysql/update-all-rows users", "mysql/unbatched-dml users"},
+		},
+		{
+			name: "AlwaysTrueWhere",
+			sql:  "DELETE FROM users WHERE 1 = 1;\nUPDATE users SET score = 0 WHERE TRUE;\nDELETE FROM users WHERE (1) OR id = 1;",
+			want: []string{
+				"mysql/delete-all-rows users", "mysql/unbatched-dml users",
+				"mysql/update-all-rows users", "mysql/unbatched-dml users",
+				"mysql/delete-all-rows users", "mysql/unbatched-dml users",
+			},
+		},
+		{
+			name: "NotAlwaysTrueWhere",
+			sql:  "DELETE FROM users WHERE 1 = 0 OR id = 1;",
+			want: []string{"mysql/unbatched-dml users"},
+		},
+		{
+			name: "UnbatchedDelete",
+			sql:  "DELETE FROM users WHERE deleted_at IS NOT NULL;",
+			want: []string{"mysql/unbatched-dml users"},
+		},
+		{
+			name: "MultiTableDelete",
+			sql:  "DELETE users, posts FROM users JOIN posts ON posts.user_id = users.id;",
+			want: []string{"mysql/unbatched-dml users", "mysql/unbatched-dml posts"},
+		},
+		{
+			name: "JoinedUpdate",
+			sql:  "UPDATE users JOIN posts ON posts.user_id = users.id SET users.score = users.score + 1;",
+			want: []string{"mysql/unbatched-dml users"},
+		},
+		{
+			name: "InsertSelect",
+			sql:  "INSERT INTO archived_users SELECT * FROM users WHERE deleted_at IS NOT NULL;",
+			want: []string{"mysql/unbatched-dml archived_users"},
+		},
+		{
+			name: "InsertSelectOnDuplicateKeyUpdate",
+			sql:  "INSERT INTO user_stats (user_id, posts) SELECT user_id, COUNT(*) FROM posts GROUP BY user_id ON DUPLICATE KEY UPDATE posts = VALUES(posts);",
+			want: []string{"mysql/unbatched-dml user_stats"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunMySQLWithOptions(tt.sql, breaql.Options{OperationalRisks: true})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+				return f.RuleID + " " + f.Object
+			}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunMySQLWithOptions() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunMySQL_DMLMessages(t *testing.T) {
+	got, err := breaql.RunMySQL("UPDATE users SET status = 'inactive', score = 0 WHERE 1 = 1;\nDELETE FROM users;")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"UPDATE with an always-true WHERE clause overwrites status, score of the rows of table users regardless of their values",
+		"DELETE without a WHERE clause deletes the rows of table users regardless of their values",
+	}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.Message }))
+	assert.Empty(t, got.OperationalRisks(), "operational risks are reported only if enabled")
+	assert.Equal(t, breaql.TableChanges{"users": {
+		"UPDATE users SET status = 'inactive', score = 0 WHERE 1 = 1;",
+		"DELETE FROM users;",
+	}}, got.Tables)
+}
@@ -0,0 +1,491 @@
+package breaql
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/samber/lo"
+)
+
+// DefaultMySQLVersion is the MySQL version assumed when Options.MySQLVersion is empty.
+const DefaultMySQLVersion = "8.0"
+
+// mysqlVersion is a MySQL server version. A version without the patch number stands for its latest patch release.
+type mysqlVersion struct {
+	text                string
+	major, minor, patch int
+}
+
+func parseMySQLVersion(s string) (mysqlVersion, error) {
+	if s == "" {
+		s = DefaultMySQLVersion
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return mysqlVersion{}, fmt.Errorf("invalid MySQL version %q: want e.g. 5.7, 8.0 or 8.0.28", s)
+	}
+	nums := []int{0, 0, math.MaxInt}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return mysqlVersion{}, fmt.Errorf("invalid MySQL version %q: want e.g. 5.7, 8.0 or 8.0.28", s)
+		}
+		nums[i] = n
+	}
+	v := mysqlVersion{text: s, major: nums[0], minor: nums[1], patch: nums[2]}
+	if !v.atLeast(5, 7, 0) {
+		return mysqlVersion{}, fmt.Errorf("unsupported MySQL version %q: 5.7 or later is required", s)
+	}
+	return v, nil
+}
+
+func (v mysqlVersion) atLeast(major, minor, patch int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	if v.minor != minor {
+		return v.minor > minor
+	}
+	return v.patch >= patch
+}
+
+// mysqlLock is the LOCK clause of InnoDB online DDL, in ascending order of strength.
+type mysqlLock int
+
+const (
+	mysqlLockNone      mysqlLock = iota + 1 // permits reads and writes
+	mysqlLockShared                         // blocks writes
+	mysqlLockExclusive                      // blocks reads and writes
+)
+
+func (l mysqlLock) String() string {
+	switch l {
+	case mysqlLockNone:
+		return "NONE"
+	case mysqlLockShared:
+		return "SHARED"
+	case mysqlLockExclusive:
+		return "EXCLUSIVE"
+	}
+	return ""
+}
+
+func (l mysqlLock) blocks() string {
+	if l == mysqlLockExclusive {
+		return "reads and writes"
+	}
+	return "writes"
+}
+
+func mysqlLockOf(tp ast.LockType) mysqlLock {
+	switch tp {
+	case ast.LockTypeNone:
+		return mysqlLockNone
+	case ast.LockTypeShared:
+		return mysqlLockShared
+	case ast.LockTypeExclusive:
+		return mysqlLockExclusive
+	}
+	return 0
+}
+
+// mysqlOnlineOp is how InnoDB runs a part of a statement at best.
+// The algorithms of ast.AlgorithmType are in ascending order of preference: COPY, INPLACE and INSTANT.
+type mysqlOnlineOp struct {
+	algorithm ast.AlgorithmType // the fastest algorithm supported
+	lock      mysqlLock         // the least restrictive lock permitted; unused for INSTANT
+	reason    string            // what the part does, e.g. "column age is dropped"
+}
+
+// mysqlMetadataOp returns the operation only changing the metadata, which is INSTANT since MySQL 8.0.12.
+func mysqlMetadataOp(v mysqlVersion, reason string) mysqlOnlineOp {
+	if v.atLeast(8, 0, 12) {
+		return mysqlOnlineOp{algorithm: ast.AlgorithmTypeInstant, reason: reason}
+	}
+	return mysqlOnlineOp{algorithm: ast.AlgorithmTypeInplace, lock: mysqlLockNone, reason: reason}
+}
+
+func mysqlInplaceOp(lock mysqlLock, reason string) mysqlOnlineOp {
+	return mysqlOnlineOp{algorithm: ast.AlgorithmTypeInplace, lock: lock, reason: reason}
+}
+
+func mysqlCopyOp(reason string) mysqlOnlineOp {
+	return mysqlOnlineOp{algorithm: ast.AlgorithmTypeCopy, lock: mysqlLockShared, reason: reason}
+}
+
+// mysqlOnlineDDLFindings returns the operational risks of the given statement on the version:
+// the algorithm InnoDB runs it with if it blocks concurrent DML, or an ALGORITHM or LOCK clause failing it.
+func mysqlOnlineDDLFindings(stmt statement, schema *Schema, v mysqlVersion, stmtNode ast.StmtNode) []Finding {
+	switch n := stmtNode.(type) {
+	case *ast.AlterTableStmt:
+		table := mysqlTableName(n.Table)
+		t := schema.table(table)
+		var ops []mysqlOnlineOp
+		algorithm, lock := ast.AlgorithmTypeDefault, mysqlLock(0)
+		for _, spec := range n.Specs {
+			switch spec.Tp {
+			case ast.AlterTableAlgorithm:
+				algorithm = spec.Algorithm
+			case ast.AlterTableLock:
+				lock = mysqlLockOf(spec.LockType)
+			default:
+				ops = append(ops, mysqlAlterTableOps(v, t, n.Specs, spec)...)
+			}
+		}
+		if len(ops) == 0 {
+			return nil
+		}
+		return mysqlOnlineDDLFinding(stmt, table, v, ops, algorithm, lock)
+
+	case *ast.CreateIndexStmt:
+		table := mysqlTableName(n.Table)
+		var op mysqlOnlineOp
+		switch n.KeyType {
+		case ast.IndexKeyTypeFullText:
+			op = mysqlInplaceOp(mysqlLockShared, fmt.Sprintf("full-text index %s is added", n.IndexName))
+		case ast.IndexKeyTypeSpatial:
+			op = mysqlInplaceOp(mysqlLockShared, fmt.Sprintf("spatial index %s is added", n.IndexName))
+		default:
+			op = mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("index %s is added", n.IndexName))
+		}
+		algorithm, lock := mysqlIndexLockAndAlgorithm(n.LockAlg)
+		return mysqlOnlineDDLFinding(stmt, table, v, []mysqlOnlineOp{op}, algorithm, lock)
+
+	case *ast.DropIndexStmt:
+		table := mysqlTableName(n.Table)
+		op := mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("index %s is dropped", n.IndexName))
+		algorithm, lock := mysqlIndexLockAndAlgorithm(n.LockAlg)
+		return mysqlOnlineDDLFinding(stmt, table, v, []mysqlOnlineOp{op}, algorithm, lock)
+	}
+	return nil
+}
+
+func mysqlIndexLockAndAlgorithm(la *ast.IndexLockAndAlgorithm) (ast.AlgorithmType, mysqlLock) {
+	if la == nil {
+		return ast.AlgorithmTypeDefault, 0
+	}
+	return la.AlgorithmTp, mysqlLockOf(la.LockTp)
+}
+
+// mysqlOnlineDDLFinding returns the finding on the algorithm and the lock of a statement consisting of ops,
+// given the ALGORITHM and LOCK clauses if any, or nil if the statement permits concurrent DML.
+func mysqlOnlineDDLFinding(stmt statement, table string, v mysqlVersion, ops []mysqlOnlineOp, requested ast.AlgorithmType, requestedLock mysqlLock) []Finding {
+	// The statement runs with the slowest algorithm and the strictest lock among its parts.
+	algorithm, lock := ast.AlgorithmTypeInstant, mysqlLock(0)
+	for _, op := range ops {
+		algorithm = min(algorithm, op.algorithm)
+	}
+	for _, op := range ops {
+		if algorithm != ast.AlgorithmTypeInstant {
+			lock = max(lock, op.lock)
+		}
+	}
+	reasons := func(match func(op mysqlOnlineOp) bool) string {
+		var rs []string
+		for _, op := range ops {
+			if match(op) {
+				rs = append(rs, op.reason)
+			}
+		}
+		return strings.Join(rs, "; ")
+	}
+
+	finding := func(ruleID, message string) []Finding {
+		f := stmt.finding(ruleID, ObjectKindTable, table, message)
+		f.Algorithm = algorithm.String()
+		f.Lock = lock.String()
+		return []Finding{f}
+	}
+	if requested > algorithm {
+		return finding("mysql/ddl-algorithm-conflict", fmt.Sprintf("ALGORITHM=%s fails on table %s as MySQL %s supports ALGORITHM=%s at best: %s",
+			requested, table, v.text, algorithm, reasons(func(op mysqlOnlineOp) bool { return op.algorithm < requested })))
+	}
+
+	// An explicit slower algorithm or stricter lock is taken as given.
+	why := ""
+	if requested != ast.AlgorithmTypeDefault && requested < algorithm {
+		algorithm, lock, why = requested, max(lock, mysqlLockNone), fmt.Sprintf("ALGORITHM=%s is given", requested)
+		if algorithm == ast.AlgorithmTypeCopy {
+			lock = max(lock, mysqlLockShared)
+		}
+	}
+	because := func(match func(op mysqlOnlineOp) bool) string {
+		if why != "" {
+			return why
+		}
+		return reasons(match)
+	}
+	if algorithm != ast.AlgorithmTypeInstant && requestedLock != 0 {
+		if requestedLock < lock {
+			return finding("mysql/ddl-algorithm-conflict", fmt.Sprintf("LOCK=%s fails on table %s as LOCK=%s is required: %s",
+				requestedLock, table, lock, because(func(op mysqlOnlineOp) bool { return op.lock > requestedLock })))
+		}
+		if requestedLock > lock {
+			lock, why = requestedLock, fmt.Sprintf("LOCK=%s is given", requestedLock)
+		}
+	}
+
+	switch {
+	case algorithm == ast.AlgorithmTypeCopy:
+		return finding("mysql/ddl-copy", fmt.Sprintf("table %s is copied with ALGORITHM=COPY, LOCK=%s, which blocks %s until it finishes: %s",
+			table, lock, lock.blocks(), because(func(op mysqlOnlineOp) bool { return op.algorithm == ast.AlgorithmTypeCopy })))
+	case lock > mysqlLockNone:
+		return finding("mysql/ddl-inplace-lock", fmt.Sprintf("table %s is altered with ALGORITHM=INPLACE, LOCK=%s, which blocks %s until it finishes: %s",
+			table, lock, lock.blocks(), because(func(op mysqlOnlineOp) bool { return op.lock == lock })))
+	default:
+		// INSTANT and INPLACE with LOCK=NONE permit concurrent DML, so they are not reported.
+		return nil
+	}
+}
+
+// mysqlAlterTableOps returns how InnoDB runs the given spec of specs on the version.
+// t is the table in the baseline schema, or nil if unknown.
+func mysqlAlterTableOps(v mysqlVersion, t *schemaTable, specs []*ast.AlterTableSpec, spec *ast.AlterTableSpec) []mysqlOnlineOp {
+	switch spec.Tp {
+	case ast.AlterTableAddColumns:
+		var ops []mysqlOnlineOp
+		for _, def := range spec.NewColumns {
+			ops = append(ops, mysqlAddColumnOp(v, def, spec.Position))
+		}
+		return ops
+
+	case ast.AlterTableDropColumn:
+		reason := fmt.Sprintf("column %s is dropped", spec.OldColumnName.Name)
+		if v.atLeast(8, 0, 29) {
+			return []mysqlOnlineOp{{algorithm: ast.AlgorithmTypeInstant, reason: reason}}
+		}
+		return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockNone, reason)}
+
+	case ast.AlterTableRenameColumn:
+		return []mysqlOnlineOp{mysqlRenameColumnOp(v, spec.OldColumnName.Name.O, spec.NewColumnName.Name.O)}
+
+	case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+		return []mysqlOnlineOp{mysqlModifyColumnOp(v, t, spec)}
+
+	case ast.AlterTableAlterColumn:
+		return []mysqlOnlineOp{mysqlMetadataOp(v, fmt.Sprintf("default of column %s is changed", spec.NewColumns[0].Name.Name))}
+
+	case ast.AlterTableAddConstraint:
+		return []mysqlOnlineOp{mysqlAddConstraintOp(v, spec.Constraint)}
+
+	case ast.AlterTableDropPrimaryKey:
+		if _, ok := lo.Find(specs, func(s *ast.AlterTableSpec) bool {
+			return s.Tp == ast.AlterTableAddConstraint && s.Constraint.Tp == ast.ConstraintPrimaryKey
+		}); ok {
+			return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockNone, "primary key is replaced")}
+		}
+		return []mysqlOnlineOp{mysqlCopyOp("primary key is dropped without adding another")}
+
+	case ast.AlterTableDropIndex:
+		return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("index %s is dropped", spec.Name))}
+
+	case ast.AlterTableDropForeignKey:
+		return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("foreign key %s is dropped", spec.Name))}
+
+	case ast.AlterTableDropCheck:
+		return []mysqlOnlineOp{mysqlMetadataOp(v, fmt.Sprintf("check constraint %s is dropped", spec.Constraint.Name))}
+
+	case ast.AlterTableAlterCheck:
+		if spec.Constraint.Enforced {
+			return []mysqlOnlineOp{mysqlCopyOp(fmt.Sprintf("check constraint %s is enforced and validated", spec.Constraint.Name))}
+		}
+		return []mysqlOnlineOp{mysqlMetadataOp(v, fmt.Sprintf("check constraint %s is no longer enforced", spec.Constraint.Name))}
+
+	case ast.AlterTableRenameIndex:
+		return []mysqlOnlineOp{mysqlMetadataOp(v, fmt.Sprintf("index %s is renamed", spec.FromKey))}
+
+	case ast.AlterTableIndexInvisible:
+		return []mysqlOnlineOp{mysqlMetadataOp(v, fmt.Sprintf("visibility of index %s is changed", spec.IndexName))}
+
+	case ast.AlterTableRenameTable:
+		return []mysqlOnlineOp{mysqlMetadataOp(v, "the table is renamed")}
+
+	case ast.AlterTableOption:
+		var ops []mysqlOnlineOp
+		for _, opt := range spec.Options {
+			ops = append(ops, mysqlTableOptionOp(opt))
+		}
+		return ops
+
+	case ast.AlterTableForce:
+		return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockNone, "FORCE rebuilds the table")}
+
+	case ast.AlterTableAddPartitions:
+		return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockShared, "partitions are added")}
+
+	case ast.AlterTableDropPartition, ast.AlterTableTruncatePartition, ast.AlterTableExchangePartition:
+		return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockExclusive, fmt.Sprintf("%s are changed", partitionList(mysqlPartitions(spec))))}
+
+	case ast.AlterTableReorganizePartition, ast.AlterTableCoalescePartitions, ast.AlterTableRebuildPartition:
+		return []mysqlOnlineOp{mysqlInplaceOp(mysqlLockShared, "rows are copied between partitions")}
+
+	case ast.AlterTablePartition, ast.AlterTableRemovePartitioning:
+		return []mysqlOnlineOp{mysqlCopyOp("partitioning of the table is changed")}
+	}
+	// Note: The other clauses, e.g. ORDER BY, are assumed to need ALGORITHM=COPY.
+	return []mysqlOnlineOp{mysqlCopyOp("the table is reorganized")}
+}
+
+// mysqlAddColumnOp returns how InnoDB adds the column defined by def at the position.
+func mysqlAddColumnOp(v mysqlVersion, def *ast.ColumnDef, position *ast.ColumnPosition) mysqlOnlineOp {
+	column := def.Name.Name.O
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionAutoIncrement:
+			return mysqlInplaceOp(mysqlLockShared, fmt.Sprintf("auto-increment column %s is added", column))
+		case ast.ColumnOptionGenerated:
+			if opt.Stored {
+				return mysqlCopyOp(fmt.Sprintf("stored generated column %s is added", column))
+			}
+			return mysqlMetadataOp(v, fmt.Sprintf("virtual generated column %s is added", column))
+		case ast.ColumnOptionPrimaryKey:
+			return mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("column %s is added as the primary key", column))
+		case ast.ColumnOptionUniqKey:
+			return mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("column %s is added with a unique index", column))
+		}
+	}
+
+	reason := fmt.Sprintf("column %s is added", column)
+	last := position == nil || position.Tp == ast.ColumnPositionNone
+	switch {
+	case v.atLeast(8, 0, 29), v.atLeast(8, 0, 12) && last:
+		return mysqlOnlineOp{algorithm: ast.AlgorithmTypeInstant, reason: reason}
+	case v.atLeast(8, 0, 12):
+		return mysqlInplaceOp(mysqlLockNone, reason+" before other columns, which is INSTANT since MySQL 8.0.29")
+	}
+	return mysqlInplaceOp(mysqlLockNone, reason)
+}
+
+func mysqlRenameColumnOp(v mysqlVersion, column, newName string) mysqlOnlineOp {
+	reason := fmt.Sprintf("column %s is renamed to %s", column, newName)
+	if v.atLeast(8, 0, 28) {
+		return mysqlOnlineOp{algorithm: ast.AlgorithmTypeInstant, reason: reason}
+	}
+	return mysqlInplaceOp(mysqlLockNone, reason)
+}
+
+// mysqlModifyColumnOp returns how InnoDB runs MODIFY COLUMN or CHANGE COLUMN.
+// Without the old definition of the column, the data type is assumed to change.
+func mysqlModifyColumnOp(v mysqlVersion, t *schemaTable, spec *ast.AlterTableSpec) mysqlOnlineOp {
+	def := spec.NewColumns[0]
+	column := def.Name.Name.O
+	if spec.Tp == ast.AlterTableChangeColumn {
+		column = spec.OldColumnName.Name.O
+	}
+	old := t.column(column)
+	if old == nil {
+		return mysqlCopyOp(fmt.Sprintf("column %s is redefined, which copies the table unless the data type is kept", column))
+	}
+
+	new := mysqlSchemaColumn(def)
+	reordered := spec.Position != nil && spec.Position.Tp != ast.ColumnPositionNone
+	sameType := mysqlSameColumnType(t, old.typ, new.typ)
+	switch {
+	case !sameType && !mysqlStorageKept(t, old.typ, new.typ):
+		return mysqlCopyOp(fmt.Sprintf("type of column %s is changed from %s to %s", column, old.typ, new.typ))
+	case reordered:
+		return mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("column %s is reordered", column))
+	case old.notNull != new.notNull:
+		return mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("nullability of column %s is changed", column))
+	case !sameType && (old.typ.name == "enum" || old.typ.name == "set"):
+		return mysqlMetadataOp(v, fmt.Sprintf("values are appended to column %s", column))
+	case !sameType:
+		return mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("column %s is extended from %s to %s", column, old.typ, new.typ))
+	case !strings.EqualFold(column, new.name):
+		return mysqlRenameColumnOp(v, column, new.name)
+	}
+	return mysqlMetadataOp(v, fmt.Sprintf("column %s is redefined with the same type", column))
+}
+
+// mysqlSameColumnType returns whether the column types in table t are the same.
+func mysqlSameColumnType(t *schemaTable, old, new columnType) bool {
+	return old.name == new.name && old.length == new.length && old.scale == new.scale && old.unsigned == new.unsigned &&
+		strings.Join(old.values, ",") == strings.Join(new.values, ",") &&
+		cmp.Or(old.charset, t.charset) == cmp.Or(new.charset, t.charset) && cmp.Or(old.collation, t.collation) == cmp.Or(new.collation, t.collation)
+}
+
+// mysqlStorageKept returns whether the new column type stores the values of the old one as they are,
+// which InnoDB changes in place: a VARCHAR extended within the same number of length bytes,
+// or ENUM and SET values appended within the same storage size.
+func mysqlStorageKept(t *schemaTable, old, new columnType) bool {
+	if cmp.Or(old.charset, t.charset) != cmp.Or(new.charset, t.charset) || cmp.Or(old.collation, t.collation) != cmp.Or(new.collation, t.collation) {
+		return false
+	}
+	switch {
+	case old.name == "varchar" && new.name == "varchar", old.name == "varbinary" && new.name == "varbinary":
+		width := mysqlCharsetWidth(cmp.Or(old.charset, t.charset))
+		return new.length >= old.length && (old.length*width < 256) == (new.length*width < 256)
+	case old.name == "enum" && new.name == "enum", old.name == "set" && new.name == "set":
+		return mysqlTypeWidens(old, new) && mysqlElemsBytes(old) == mysqlElemsBytes(new)
+	}
+	return false
+}
+
+// mysqlCharsetWidth returns the maximum number of bytes of a character in the character set.
+func mysqlCharsetWidth(charset string) int {
+	switch charset {
+	case "latin1", "ascii", "binary":
+		return 1
+	case "ucs2":
+		return 2
+	case "utf8", "utf8mb3":
+		return 3
+	}
+	return 4
+}
+
+// mysqlElemsBytes returns the storage size of ENUM and SET values.
+func mysqlElemsBytes(t columnType) int {
+	if t.name == "enum" {
+		if len(t.values) < 256 {
+			return 1
+		}
+		return 2
+	}
+	n := (len(t.values) + 7) / 8
+	if n > 4 {
+		return 8
+	}
+	return n
+}
+
+// mysqlAddConstraintOp returns how InnoDB adds the given index or constraint.
+func mysqlAddConstraintOp(v mysqlVersion, cons *ast.Constraint) mysqlOnlineOp {
+	switch cons.Tp {
+	case ast.ConstraintPrimaryKey:
+		return mysqlInplaceOp(mysqlLockNone, "primary key is added")
+	case ast.ConstraintFulltext:
+		return mysqlInplaceOp(mysqlLockShared, fmt.Sprintf("full-text index %s is added", cons.Name))
+	case ast.ConstraintForeignKey:
+		return mysqlCopyOp(fmt.Sprintf("foreign key %s is added, which supports ALGORITHM=INPLACE only with foreign_key_checks disabled", cons.Name))
+	case ast.ConstraintCheck:
+		if !cons.Enforced {
+			return mysqlMetadataOp(v, fmt.Sprintf("check constraint %s is added NOT ENFORCED", cons.Name))
+		}
+		return mysqlCopyOp(fmt.Sprintf("check constraint %s is added and validated", cons.Name))
+	}
+	return mysqlInplaceOp(mysqlLockNone, fmt.Sprintf("index %s is added", cons.Name))
+}
+
+// mysqlTableOptionOp returns how InnoDB changes the given table option.
+func mysqlTableOptionOp(opt *ast.TableOption) mysqlOnlineOp {
+	switch opt.Tp {
+	case ast.TableOptionCharset, ast.TableOptionCollate:
+		if opt.UintValue == ast.TableOptionCharsetWithConvertTo {
+			return mysqlCopyOp(fmt.Sprintf("columns are converted to character set %s", opt.StrValue))
+		}
+		return mysqlInplaceOp(mysqlLockShared, "default character set of the table is changed")
+	case ast.TableOptionEngine:
+		return mysqlInplaceOp(mysqlLockNone, "ENGINE rebuilds the table")
+	case ast.TableOptionRowFormat, ast.TableOptionKeyBlockSize:
+		return mysqlInplaceOp(mysqlLockNone, "row format of the table is changed")
+	case ast.TableOptionAutoIncrement, ast.TableOptionComment,
+		ast.TableOptionStatsPersistent, ast.TableOptionStatsAutoRecalc, ast.TableOptionStatsSamplePages:
+		return mysqlInplaceOp(mysqlLockNone, "table options are changed")
+	}
+	return mysqlCopyOp("table options are changed")
+}
@@ -0,0 +1,150 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMySQL_OnlineDDL(t *testing.T) {
+	baseline, err := breaql.LoadMySQLSchema(mysqlBaseline)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		version string
+		sql     string
+		want    []string // rule IDs, algorithms and locks of the operational findings
+	}{
+		{name: "CreateTable", sql: "CREATE TABLE posts (id INT PRIMARY KEY);"},
+		{name: "OnlyClauses", sql: "ALTER TABLE users ALGORITHM=INPLACE, LOCK=NONE;"},
+		{name: "AddColumn", sql: "ALTER TABLE users ADD COLUMN age INT, ADD COLUMN position INT FIRST;"},
+		{
+			name:    "AddColumnFirstBefore8029",
+			version: "8.0.28",
+			sql:     "ALTER TABLE users ADD COLUMN position INT FIRST;",
+		},
+		{
+			name:    "AddColumnOn57",
+			version: "5.7",
+			sql:     "ALTER TABLE users ADD COLUMN age INT;",
+		},
+		{
+			name: "AddAutoIncrementColumn",
+			sql:  "ALTER TABLE logs ADD COLUMN seq INT AUTO_INCREMENT;",
+			want: []string{"mysql/ddl-inplace-lock INPLACE SHARED"},
+		},
+		{
+			name: "AddStoredGeneratedColumn",
+			sql:  "ALTER TABLE users ADD COLUMN name_length INT AS (CHAR_LENGTH(name)) STORED;",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{
+			name:    "DropColumnBefore8029",
+			version: "8.0.28",
+			sql:     "ALTER TABLE users DROP COLUMN bio;",
+		},
+		{name: "RenameColumnAndSetDefault", sql: "ALTER TABLE users RENAME COLUMN bio TO profile, ALTER COLUMN score SET DEFAULT 0;"},
+		{
+			name:    "RenameColumnOn57",
+			version: "5.7",
+			sql:     "ALTER TABLE users CHANGE bio profile TEXT;",
+		},
+		{name: "ExtendVarchar", sql: "ALTER TABLE users MODIFY nickname VARCHAR(60);"},
+		{
+			name: "ExtendVarcharOverLengthByte",
+			sql:  "ALTER TABLE users MODIFY nickname VARCHAR(100);",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{name: "AppendEnumValue", sql: "ALTER TABLE users MODIFY status ENUM('active','inactive','banned');"},
+		{name: "SetNotNull", sql: "ALTER TABLE users MODIFY nickname VARCHAR(50) NOT NULL;"},
+		{
+			name: "ChangeType",
+			sql:  "ALTER TABLE users MODIFY score BIGINT;",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{
+			name: "ModifyUnknownColumn",
+			sql:  "ALTER TABLE logs MODIFY message TEXT;",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{name: "AddIndexes", sql: "ALTER TABLE users ADD INDEX idx_name (name), ADD UNIQUE KEY uniq_code (code);\nCREATE INDEX idx_score ON users (score);\nDROP INDEX idx_name ON users;"},
+		{
+			name: "AddFulltextIndex",
+			sql:  "CREATE FULLTEXT INDEX ft_bio ON users (bio);",
+			want: []string{"mysql/ddl-inplace-lock INPLACE SHARED"},
+		},
+		{
+			name: "AddForeignKey",
+			sql:  "ALTER TABLE posts ADD CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id);",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{name: "ReplacePrimaryKey", sql: "ALTER TABLE users DROP PRIMARY KEY, ADD PRIMARY KEY (id, name);"},
+		{
+			name: "DropPrimaryKey",
+			sql:  "ALTER TABLE users DROP PRIMARY KEY;",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{
+			name: "ConvertCharset",
+			sql:  "ALTER TABLE users CONVERT TO CHARACTER SET utf8mb4;",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{
+			name: "SlowestSpecWins",
+			sql:  "ALTER TABLE users ADD COLUMN age INT, ADD INDEX idx_name (name), MODIFY score BIGINT;",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{name: "ExplicitInstant", sql: "ALTER TABLE users ADD COLUMN age INT, ALGORITHM=INSTANT;"},
+		{
+			name:    "InstantNotSupported",
+			version: "5.7",
+			sql:     "ALTER TABLE users ADD COLUMN age INT, ALGORITHM=INSTANT;",
+			want:    []string{"mysql/ddl-algorithm-conflict INPLACE NONE"},
+		},
+		{
+			name: "InplaceNotSupported",
+			sql:  "ALTER TABLE users MODIFY score BIGINT, ALGORITHM=INPLACE;",
+			want: []string{"mysql/ddl-algorithm-conflict COPY SHARED"},
+		},
+		{
+			name: "LockNoneNotSupported",
+			sql:  "CREATE FULLTEXT INDEX ft_bio ON users (bio) LOCK=NONE;",
+			want: []string{"mysql/ddl-algorithm-conflict INPLACE SHARED"},
+		},
+		{
+			name: "ExplicitCopy",
+			sql:  "ALTER TABLE users ADD INDEX idx_name (name), ALGORITHM=COPY;",
+			want: []string{"mysql/ddl-copy COPY SHARED"},
+		},
+		{
+			name: "ExplicitExclusiveLock",
+			sql:  "ALTER TABLE users ADD INDEX idx_name (name), LOCK=EXCLUSIVE;",
+			want: []string{"mysql/ddl-inplace-lock INPLACE EXCLUSIVE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunMySQLWithOptions(tt.sql, breaql.Options{Baseline: baseline, OperationalRisks: true, MySQLVersion: tt.version})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.OperationalRisks(), func(f breaql.Finding, _ int) string {
+				return f.RuleID + " " + f.Algorithm + " " + f.Lock
+			}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunMySQLWithOptions() operational risks mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunMySQL_InvalidVersion(t *testing.T) {
+	for _, version := range []string{"8", "eight", "5.6", "8.0.x"} {
+		_, err := breaql.RunMySQLWithOptions("ALTER TABLE users DROP COLUMN age;", breaql.Options{MySQLVersion: version})
+		assert.Error(t, err, version)
+	}
+}
@@ -0,0 +1,419 @@
+package breaql
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+)
+
+// LoadMySQLSchema builds a catalog from the CREATE TABLE and CREATE VIEW statements in sql, e.g. the output of `mysqldump --no-data`.
+// Other statements altering the tables are applied as well, and the rest are ignored.
+func LoadMySQLSchema(sql string) (*Schema, error) {
+	stmtNodes, stmts, _, err := parseMySQL(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{driver: "mysql"}
+	for i, stmtNode := range stmtNodes {
+		applyMySQLStmt(schema, stmts[i], stmtNode)
+	}
+	return schema, nil
+}
+
+// applyMySQLStmt updates the catalog with the given statement.
+func applyMySQLStmt(schema *Schema, stmt statement, stmtNode ast.StmtNode) {
+	switch n := stmtNode.(type) {
+	case *ast.CreateTableStmt:
+		if n.ReferTable != nil {
+			if refer := schema.table(mysqlTableName(n.ReferTable)); refer != nil {
+				t := refer.clone()
+				t.name = mysqlTableName(n.Table)
+				t.stmt = stmt
+				schema.putTable(t)
+			}
+			return
+		}
+		t := mysqlSchemaTable(n)
+		t.stmt = stmt
+		schema.putTable(t)
+
+	case *ast.CreateViewStmt:
+		schema.putView(&schemaView{name: mysqlTableName(n.ViewName), columns: mysqlViewColumns(n)})
+
+	case *ast.DropTableStmt:
+		for _, tn := range n.Tables {
+			if n.IsView {
+				schema.dropView(mysqlTableName(tn))
+			} else {
+				schema.dropTable(mysqlTableName(tn))
+			}
+		}
+
+	case *ast.RenameTableStmt:
+		for _, ttt := range n.TableToTables {
+			if t := schema.table(mysqlTableName(ttt.OldTable)); t != nil {
+				t.name = mysqlTableName(ttt.NewTable)
+			} else if v := schema.view(mysqlTableName(ttt.OldTable)); v != nil {
+				v.name = mysqlTableName(ttt.NewTable)
+			}
+		}
+
+	case *ast.CreateIndexStmt:
+		if t := schema.table(mysqlTableName(n.Table)); t != nil {
+			t.putIndex(&schemaIndex{
+				name:    n.IndexName,
+				columns: mysqlKeyColumns(n.IndexPartSpecifications),
+				unique:  n.KeyType == ast.IndexKeyTypeUnique,
+			})
+		}
+
+	case *ast.DropIndexStmt:
+		if t := schema.table(mysqlTableName(n.Table)); t != nil {
+			t.dropIndex(n.IndexName)
+		}
+
+	case *ast.AlterTableStmt:
+		t := schema.table(mysqlTableName(n.Table))
+		if t == nil {
+			return
+		}
+		for _, spec := range n.Specs {
+			switch spec.Tp {
+			case ast.AlterTableAddColumns:
+				for _, def := range spec.NewColumns {
+					t.putColumn(def.Name.Name.O, mysqlSchemaColumn(def))
+					mysqlApplyColumnOptions(t, def)
+				}
+			case ast.AlterTableModifyColumn:
+				t.putColumn(spec.NewColumns[0].Name.Name.O, mysqlSchemaColumn(spec.NewColumns[0]))
+			case ast.AlterTableChangeColumn:
+				t.putColumn(spec.OldColumnName.Name.O, mysqlSchemaColumn(spec.NewColumns[0]))
+			case ast.AlterTableRenameColumn:
+				if c := t.column(spec.OldColumnName.Name.O); c != nil {
+					c.name = spec.NewColumnName.Name.O
+				}
+			case ast.AlterTableDropColumn:
+				t.dropColumn(spec.OldColumnName.Name.O)
+			case ast.AlterTableAddConstraint:
+				mysqlApplyConstraint(t, spec.Constraint)
+			case ast.AlterTableDropIndex:
+				t.dropIndex(spec.Name)
+			case ast.AlterTableRenameIndex:
+				if idx := t.index(spec.FromKey.O); idx != nil {
+					idx.name = spec.ToKey.O
+				}
+			case ast.AlterTableDropPrimaryKey:
+				if pk := t.primaryKey(); pk != nil {
+					t.dropConstraint(pk.name)
+				}
+			case ast.AlterTableDropForeignKey:
+				t.dropConstraint(spec.Name)
+			case ast.AlterTableDropCheck:
+				t.dropConstraint(spec.Constraint.Name)
+			case ast.AlterTableRenameTable:
+				t.name = mysqlTableName(spec.NewTable)
+			}
+		}
+	}
+}
+
+func mysqlSchemaTable(n *ast.CreateTableStmt) *schemaTable {
+	t := &schemaTable{name: mysqlTableName(n.Table)}
+	for _, opt := range n.Options {
+		switch opt.Tp {
+		case ast.TableOptionCharset:
+			t.charset = strings.ToLower(opt.StrValue)
+		case ast.TableOptionCollate:
+			t.collation = strings.ToLower(opt.StrValue)
+		}
+	}
+	for _, def := range n.Cols {
+		t.columns = append(t.columns, mysqlSchemaColumn(def))
+	}
+	for _, def := range n.Cols {
+		mysqlApplyColumnOptions(t, def)
+	}
+	for _, cons := range n.Constraints {
+		mysqlApplyConstraint(t, cons)
+	}
+	return t
+}
+
+// mysqlApplyColumnOptions adds the keys and constraints defined along with the column.
+func mysqlApplyColumnOptions(t *schemaTable, def *ast.ColumnDef) {
+	column := def.Name.Name.O
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionPrimaryKey:
+			t.putConstraint(&schemaConstraint{name: "PRIMARY", kind: constraintPrimaryKey, columns: []string{column}})
+		case ast.ColumnOptionUniqKey:
+			t.putIndex(&schemaIndex{name: column, columns: []string{column}, unique: true})
+		case ast.ColumnOptionCheck:
+			name := opt.ConstraintName
+			if name == "" {
+				name = mysqlGeneratedName(t, "_chk_")
+			}
+			t.putConstraint(&schemaConstraint{name: name, kind: constraintCheck, columns: []string{column}})
+		}
+	}
+}
+
+// mysqlApplyConstraint adds the key or constraint to the table.
+// Unnamed ones are named after the rules of MySQL.
+func mysqlApplyConstraint(t *schemaTable, cons *ast.Constraint) {
+	columns := mysqlKeyColumns(cons.Keys)
+	switch cons.Tp {
+	case ast.ConstraintPrimaryKey:
+		t.putConstraint(&schemaConstraint{name: "PRIMARY", kind: constraintPrimaryKey, columns: columns})
+		// Columns in a primary key are implicitly NOT NULL.
+		for _, column := range columns {
+			if c := t.column(column); c != nil {
+				c.notNull = true
+			}
+		}
+
+	case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintFulltext, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex:
+		name := cons.Name
+		if name == "" && len(columns) > 0 {
+			name = columns[0]
+		}
+		unique := cons.Tp == ast.ConstraintUniq || cons.Tp == ast.ConstraintUniqKey || cons.Tp == ast.ConstraintUniqIndex
+		t.putIndex(&schemaIndex{name: name, columns: columns, unique: unique})
+
+	case ast.ConstraintForeignKey:
+		name := cons.Name
+		if name == "" {
+			name = mysqlGeneratedName(t, "_ibfk_")
+		}
+		fk := &schemaConstraint{name: name, kind: constraintForeignKey, columns: columns}
+		if cons.Refer != nil {
+			fk.references = mysqlTableName(cons.Refer.Table)
+		}
+		t.putConstraint(fk)
+
+	case ast.ConstraintCheck:
+		name := cons.Name
+		if name == "" {
+			name = mysqlGeneratedName(t, "_chk_")
+		}
+		t.putConstraint(&schemaConstraint{name: name, kind: constraintCheck, columns: columns})
+	}
+}
+
+// mysqlGeneratedName returns the name MySQL gives to the next unnamed constraint, e.g. users_ibfk_1.
+func mysqlGeneratedName(t *schemaTable, infix string) string {
+	prefix := unqualified(t.name) + infix
+	for i := 1; ; i++ {
+		if name := fmt.Sprintf("%s%d", prefix, i); t.constraint(name) == nil {
+			return name
+		}
+	}
+}
+
+func mysqlKeyColumns(keys []*ast.IndexPartSpecification) []string {
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.Column != nil {
+			columns = append(columns, key.Column.Name.O)
+		} else {
+			columns = append(columns, "(expression)")
+		}
+	}
+	return columns
+}
+
+func mysqlSchemaColumn(def *ast.ColumnDef) *schemaColumn {
+	tp := def.Tp
+	text := tp.String()
+	c := &schemaColumn{
+		name: def.Name.Name.O,
+		typ: columnType{
+			name:     strings.ToLower(strings.FieldsFunc(text, func(r rune) bool { return r == '(' || r == ' ' })[0]),
+			length:   tp.GetFlen(),
+			scale:    tp.GetDecimal(),
+			unsigned: mysql.HasUnsignedFlag(tp.GetFlag()),
+			charset:  strings.ToLower(tp.GetCharset()),
+			values:   tp.GetElems(),
+		},
+	}
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case ast.ColumnOptionNotNull, ast.ColumnOptionPrimaryKey:
+			c.notNull = true
+		case ast.ColumnOptionNull:
+			c.notNull = false
+		case ast.ColumnOptionCollate:
+			c.typ.collation = strings.ToLower(opt.StrValue)
+		}
+	}
+	if c.typ.collation == "" {
+		c.typ.collation = strings.ToLower(tp.GetCollate())
+	}
+	return c
+}
+
+// mysqlColumnFindings compares the old definition of a column in oldT with the new one in newT
+// and returns the breaking differences.
+func mysqlColumnFindings(stmt statement, table string, oldT, newT *schemaTable, old, new *schemaColumn) []Finding {
+	var findings []Finding
+	add := func(ruleID, message string) {
+		f := stmt.finding(ruleID, ObjectKindTable, table, message)
+		f.Column = old.name
+		findings = append(findings, f)
+	}
+
+	if !mysqlTypeWidens(old.typ, new.typ) {
+		add("mysql/narrow-column-type", fmt.Sprintf("type of column %s of table %s is changed from %s to %s, which may not hold the existing values",
+			old.name, table, old.typ, new.typ))
+	}
+	if mysqlIntegerRank(old.typ.name) > 0 && mysqlIntegerRank(new.typ.name) > 0 && old.typ.unsigned != new.typ.unsigned &&
+		!(old.typ.unsigned && mysqlIntegerRank(new.typ.name) > mysqlIntegerRank(old.typ.name)) {
+		add("mysql/change-column-signedness", fmt.Sprintf("column %s of table %s is changed from %s to %s",
+			old.name, table, signedness(old.typ.unsigned), signedness(new.typ.unsigned)))
+	}
+	if mysqlHasCharset(old.typ.name) && mysqlHasCharset(new.typ.name) {
+		oldCharset, newCharset := cmp.Or(old.typ.charset, oldT.charset), cmp.Or(new.typ.charset, newT.charset)
+		oldCollation, newCollation := cmp.Or(old.typ.collation, oldT.collation), cmp.Or(new.typ.collation, newT.collation)
+		switch {
+		case oldCharset != "" && newCharset != "" && oldCharset != newCharset && !mysqlCharsetWidens(oldCharset, newCharset):
+			add("mysql/change-column-charset", fmt.Sprintf("character set of column %s of table %s is changed from %s to %s",
+				old.name, table, oldCharset, newCharset))
+		case oldCharset == newCharset && oldCollation != "" && newCollation != "" && oldCollation != newCollation:
+			add("mysql/change-column-charset", fmt.Sprintf("collation of column %s of table %s is changed from %s to %s",
+				old.name, table, oldCollation, newCollation))
+		}
+	}
+	if !old.notNull && new.notNull {
+		add("mysql/column-set-not-null", fmt.Sprintf("column %s of table %s becomes NOT NULL, which fails on existing NULL values and rejects writers omitting it",
+			old.name, table))
+	}
+	return findings
+}
+
+// mysqlTypeWidens returns whether every value of the old type is kept as is in the new type.
+func mysqlTypeWidens(old, new columnType) bool {
+	switch {
+	case mysqlIntegerRank(old.name) > 0 && mysqlIntegerRank(new.name) > 0:
+		// The signedness is checked separately.
+		return mysqlIntegerRank(new.name) >= mysqlIntegerRank(old.name)
+
+	case mysqlIntegerRank(old.name) > 0 && mysqlIsDecimal(new.name):
+		p, s := mysqlDecimalPrecision(new)
+		return p-s >= mysqlIntegerDigits[old.name]
+
+	case mysqlIsDecimal(old.name) && mysqlIsDecimal(new.name):
+		op, os := mysqlDecimalPrecision(old)
+		np, ns := mysqlDecimalPrecision(new)
+		return np-ns >= op-os && ns >= os
+
+	case old.name == "float" && (new.name == "float" || new.name == "double"), old.name == "double" && new.name == "double":
+		return true
+
+	case mysqlStringFamily(old.name) != "" && mysqlStringFamily(old.name) == mysqlStringFamily(new.name):
+		return mysqlStringCapacity(new) >= mysqlStringCapacity(old)
+
+	case old.name == "enum" && new.name == "enum", old.name == "set" && new.name == "set":
+		// Values may only be appended, as reordering changes the stored indexes.
+		return len(new.values) >= len(old.values) && slices.Equal(new.values[:len(old.values)], old.values)
+
+	case old.name == "date" && new.name == "datetime":
+		return true
+
+	case slices.Contains([]string{"datetime", "timestamp", "time"}, old.name) && old.name == new.name:
+		return max(new.scale, 0) >= max(old.scale, 0)
+
+	case old.name == "bit" && new.name == "bit":
+		return max(new.length, 1) >= max(old.length, 1)
+
+	default:
+		return old.name == new.name
+	}
+}
+
+var mysqlIntegerDigits = map[string]int{"tinyint": 3, "smallint": 5, "mediumint": 8, "int": 10, "integer": 10, "bigint": 20}
+
+func mysqlIntegerRank(name string) int {
+	switch name {
+	case "tinyint", "bool", "boolean":
+		return 1
+	case "smallint":
+		return 2
+	case "mediumint":
+		return 3
+	case "int", "integer":
+		return 4
+	case "bigint":
+		return 8
+	}
+	return 0
+}
+
+func mysqlIsDecimal(name string) bool {
+	return name == "decimal" || name == "numeric"
+}
+
+// mysqlDecimalPrecision returns the precision and scale of a DECIMAL with the defaults filled in.
+func mysqlDecimalPrecision(t columnType) (int, int) {
+	p, s := t.length, t.scale
+	if p < 0 {
+		p = 10
+	}
+	if s < 0 {
+		s = 0
+	}
+	return p, s
+}
+
+func mysqlStringFamily(name string) string {
+	switch name {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		return "text"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return "binary"
+	}
+	return ""
+}
+
+// mysqlStringCapacity returns the maximum length of the values of a string type.
+func mysqlStringCapacity(t columnType) int {
+	switch t.name {
+	case "tinytext", "tinyblob":
+		return 1<<8 - 1
+	case "text", "blob":
+		return 1<<16 - 1
+	case "mediumtext", "mediumblob":
+		return 1<<24 - 1
+	case "longtext", "longblob":
+		return 1<<32 - 1
+	case "char", "binary":
+		return max(t.length, 1)
+	}
+	return t.length
+}
+
+func mysqlHasCharset(name string) bool {
+	return mysqlStringFamily(name) == "text" || name == "enum" || name == "set"
+}
+
+// mysqlCharsetWidens returns whether every character in the old character set can be stored in the new one.
+func mysqlCharsetWidens(old, new string) bool {
+	switch old {
+	case "ascii":
+		return strings.HasPrefix(new, "utf8")
+	case "utf8", "utf8mb3":
+		return new == "utf8mb4" || new == "utf8" || new == "utf8mb3"
+	}
+	return false
+}
+
+func signedness(unsigned bool) string {
+	if unsigned {
+		return "UNSIGNED"
+	}
+	return "SIGNED"
+}
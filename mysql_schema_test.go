@@ -0,0 +1,228 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mysqlBaseline = `
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!50503 SET character_set_client = utf8mb4 */;
+DROP TABLE IF EXISTS users;
+CREATE TABLE users (
+  id int unsigned NOT NULL AUTO_INCREMENT,
+  name varchar(50) NOT NULL,
+  nickname varchar(50) DEFAULT NULL,
+  bio text,
+  legacy_name varchar(50) CHARACTER SET utf8mb3 DEFAULT NULL,
+  code char(8) CHARACTER SET ascii DEFAULT NULL,
+  score int DEFAULT NULL,
+  balance decimal(10,2) DEFAULT NULL,
+  status enum('active','inactive') DEFAULT NULL,
+  created_at datetime DEFAULT NULL,
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+/*!40101 SET character_set_client = @saved_cs_client */;
+`
+
+func TestRunMySQL_Baseline(t *testing.T) {
+	baseline, err := breaql.LoadMySQLSchema(mysqlBaseline)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs and columns of the findings
+	}{
+		{name: "WidenVarchar", sql: "ALTER TABLE users MODIFY name VARCHAR(100) NOT NULL;"},
+		{name: "WidenInt", sql: "ALTER TABLE users MODIFY score BIGINT;"},
+		{name: "UnsignedToLargerSigned", sql: "ALTER TABLE users MODIFY id BIGINT NOT NULL AUTO_INCREMENT;"},
+		{name: "VarcharToText", sql: "ALTER TABLE users MODIFY nickname TEXT;"},
+		{name: "WidenDecimal", sql: "ALTER TABLE users MODIFY balance DECIMAL(12,4);"},
+		{name: "AppendEnumValue", sql: "ALTER TABLE users MODIFY status ENUM('active','inactive','banned');"},
+		{name: "AddFractionalSeconds", sql: "ALTER TABLE users MODIFY created_at DATETIME(3);"},
+		{name: "Utf8mb3ToUtf8mb4", sql: "ALTER TABLE users MODIFY legacy_name VARCHAR(50) CHARACTER SET utf8mb4;"},
+		{name: "AsciiToUtf8mb4", sql: "ALTER TABLE users MODIFY code CHAR(8) CHARACTER SET utf8mb4;"},
+		{name: "KeepNotNull", sql: "ALTER TABLE users MODIFY name VARCHAR(50) NOT NULL;"},
+		{name: "ChangeColumn", sql: "ALTER TABLE users CHANGE nickname nickname VARCHAR(80);"},
+		{
+			name: "NarrowVarchar",
+			sql:  "ALTER TABLE users MODIFY name VARCHAR(20) NOT NULL;",
+			want: []string{"mysql/narrow-column-type name"},
+		},
+		{
+			name: "NarrowInt",
+			sql:  "ALTER TABLE users MODIFY score SMALLINT;",
+			want: []string{"mysql/narrow-column-type score"},
+		},
+		{
+			name: "TextToVarchar",
+			sql:  "ALTER TABLE users MODIFY bio VARCHAR(255);",
+			want: []string{"mysql/narrow-column-type bio"},
+		},
+		{
+			name: "NarrowDecimalScale",
+			sql:  "ALTER TABLE users MODIFY balance DECIMAL(10,1);",
+			want: []string{"mysql/narrow-column-type balance"},
+		},
+		{
+			name: "ReorderEnum",
+			sql:  "ALTER TABLE users MODIFY status ENUM('inactive','active');",
+			want: []string{"mysql/narrow-column-type status"},
+		},
+		{
+			name: "SignedToUnsigned",
+			sql:  "ALTER TABLE users MODIFY score INT UNSIGNED;",
+			want: []string{"mysql/change-column-signedness score"},
+		},
+		{
+			name: "UnsignedToSigned",
+			sql:  "ALTER TABLE users MODIFY id INT NOT NULL AUTO_INCREMENT;",
+			want: []string{"mysql/change-column-signedness id"},
+		},
+		{
+			name: "Utf8mb4ToLatin1",
+			sql:  "ALTER TABLE users MODIFY nickname VARCHAR(50) CHARACTER SET latin1;",
+			want: []string{"mysql/change-column-charset nickname"},
+		},
+		{
+			name: "ChangeCollation",
+			sql:  "ALTER TABLE users MODIFY nickname VARCHAR(50) COLLATE utf8mb4_bin;",
+			want: []string{"mysql/change-column-charset nickname"},
+		},
+		{
+			name: "SetNotNull",
+			sql:  "ALTER TABLE users MODIFY nickname VARCHAR(50) NOT NULL;",
+			want: []string{"mysql/column-set-not-null nickname"},
+		},
+		{
+			name: "ModifyUnknownColumnNotNull",
+			sql:  "ALTER TABLE users MODIFY missing INT NOT NULL;",
+			want: []string{"mysql/modify-column missing"},
+		},
+		{name: "AddNullableColumn", sql: "ALTER TABLE users ADD COLUMN age INT;"},
+		{name: "AddNotNullColumnWithDefault", sql: "ALTER TABLE users ADD COLUMN age INT NOT NULL DEFAULT 0;"},
+		{name: "AddAutoIncrementColumn", sql: "ALTER TABLE logs ADD COLUMN id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY;"},
+		{
+			name: "AddNotNullColumn",
+			sql:  "ALTER TABLE users ADD COLUMN age INT NOT NULL, ADD COLUMN (rank_a INT NOT NULL, rank_b INT);",
+			want: []string{"mysql/add-not-null-column age", "mysql/add-not-null-column rank_a"},
+		},
+		{name: "SetDefault", sql: "ALTER TABLE users ALTER COLUMN name SET DEFAULT '';"},
+		{name: "DropDefaultOfNullable", sql: "ALTER TABLE users ALTER COLUMN nickname DROP DEFAULT;"},
+		{
+			name: "DropDefaultOfNotNull",
+			sql:  "ALTER TABLE users ALTER COLUMN name DROP DEFAULT;",
+			want: []string{"mysql/drop-column-default name"},
+		},
+		{
+			name: "DropDefaultOfUnknownColumn",
+			sql:  "ALTER TABLE users ALTER COLUMN missing DROP DEFAULT;",
+			want: []string{"mysql/drop-column-default missing"},
+		},
+		{
+			name: "ChangeColumnNarrow",
+			sql:  "ALTER TABLE users CHANGE COLUMN score points TINYINT NOT NULL;",
+			want: []string{"mysql/rename-column score", "mysql/narrow-column-type score", "mysql/column-set-not-null score"},
+		},
+		{
+			name: "PreviousStatementsApplied",
+			sql: `ALTER TABLE users ADD COLUMN age TINYINT UNSIGNED;
+				ALTER TABLE users MODIFY age SMALLINT;`,
+		},
+		{
+			name: "UnknownColumn",
+			sql:  "ALTER TABLE users MODIFY missing INT;",
+			want: []string{"mysql/modify-column missing"},
+		},
+		{
+			name: "UnknownTable",
+			sql:  "ALTER TABLE others MODIFY name VARCHAR(100);",
+			want: []string{"mysql/modify-column name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunMySQLWithOptions(tt.sql, breaql.Options{Baseline: baseline})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID + " " + f.Column }), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunMySQLWithOptions() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// The baseline is not modified by the runs.
+	got, err := breaql.RunMySQLWithOptions("ALTER TABLE users MODIFY age SMALLINT;", breaql.Options{Baseline: baseline})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql/modify-column"}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID }))
+}
+
+func TestRunMySQL_BaselineViews(t *testing.T) {
+	baseline, err := breaql.LoadMySQLSchema(mysqlBaseline + `
+/*!50001 CREATE ALGORITHM=UNDEFINED */
+/*!50013 DEFINER=` + "`root`@`%`" + ` SQL SECURITY DEFINER */
+/*!50001 VIEW ` + "`active_users` AS select `users`.`id` AS `id`,`users`.`name` AS `name` from `users`" + ` */;
+CREATE VIEW user_scores (user_id, score) AS SELECT id, score FROM users;
+CREATE VIEW everything AS SELECT * FROM users;`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs and columns of the findings
+	}{
+		{name: "AddColumn", sql: "CREATE OR REPLACE VIEW active_users AS SELECT id, name, bio FROM users;"},
+		{name: "ReorderColumns", sql: "CREATE OR REPLACE VIEW active_users AS SELECT name, id FROM users WHERE score > 0;"},
+		{name: "AliasedExpression", sql: "CREATE OR REPLACE VIEW active_users AS SELECT id, UPPER(name) AS name FROM users;"},
+		{name: "UnionOfSameColumns", sql: "CREATE OR REPLACE VIEW user_scores AS SELECT id AS user_id, score FROM users UNION SELECT 0, 0;"},
+		{
+			name: "RemoveColumn",
+			sql:  "CREATE OR REPLACE VIEW active_users AS SELECT id FROM users;",
+			want: []string{"mysql/change-view-columns name"},
+		},
+		{
+			name: "RenameColumnsByList",
+			sql:  "CREATE OR REPLACE VIEW user_scores (id, points) AS SELECT id, score FROM users;",
+			want: []string{"mysql/change-view-columns user_id", "mysql/change-view-columns score"},
+		},
+		{
+			name: "UnknownColumns",
+			sql:  "CREATE OR REPLACE VIEW everything AS SELECT id FROM users;",
+			want: []string{"mysql/replace-view "},
+		},
+		{
+			name: "UnknownView",
+			sql:  "CREATE OR REPLACE VIEW others AS SELECT id FROM users;",
+			want: []string{"mysql/replace-view "},
+		},
+		{
+			name: "ViewCreatedBefore",
+			sql: `CREATE VIEW others AS SELECT id, name FROM users;
+				CREATE OR REPLACE VIEW others AS SELECT id, bio FROM users;`,
+			want: []string{"mysql/change-view-columns name"},
+		},
+		{
+			name: "DroppedView",
+			sql: `DROP VIEW active_users;
+				CREATE OR REPLACE VIEW active_users AS SELECT id FROM users;`,
+			want: []string{"mysql/drop-view ", "mysql/replace-view "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunMySQLWithOptions(tt.sql, breaql.Options{Baseline: baseline})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID + " " + f.Column }), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunMySQLWithOptions() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package breaql_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/ebi-yade/breaql"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRunMySQL(t *testing.T) {
@@ -52,7 +56,8 @@ func TestRunMySQL(t *testing.T) {
 			name: "AlterTableDropColumn",
 			sql:  "ALTER TABLE test_table DROP COLUMN column_name;",
 			want: breaql.BreakingChanges{
-				Tables: breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP COLUMN column_name;"}},
+				Tables:  breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP COLUMN column_name;"}},
+				Columns: breaql.ColumnChanges{"test_table.column_name": {"ALTER TABLE test_table DROP COLUMN column_name;"}},
 			},
 			expectsErr: false,
 		},
@@ -60,7 +65,8 @@ func TestRunMySQL(t *testing.T) {
 			name: "AlterTableDropIndex",
 			sql:  "ALTER TABLE test_table DROP INDEX index_name;",
 			want: breaql.BreakingChanges{
-				Tables: breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP INDEX index_name;"}},
+				Tables:      breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP INDEX index_name;"}},
+				Constraints: breaql.ConstraintChanges{"test_table.index_name": {"ALTER TABLE test_table DROP INDEX index_name;"}},
 			},
 			expectsErr: false,
 		},
@@ -68,7 +74,17 @@ func TestRunMySQL(t *testing.T) {
 			name: "AlterTableDropForeignKey",
 			sql:  "ALTER TABLE test_table DROP FOREIGN KEY fk_name;",
 			want: breaql.BreakingChanges{
-				Tables: breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP FOREIGN KEY fk_name;"}},
+				Tables:      breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP FOREIGN KEY fk_name;"}},
+				Constraints: breaql.ConstraintChanges{"test_table.fk_name": {"ALTER TABLE test_table DROP FOREIGN KEY fk_name;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterTableDropCheck",
+			sql:  "ALTER TABLE test_table DROP CHECK chk1;",
+			want: breaql.BreakingChanges{
+				Tables:      breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP CHECK chk1;"}},
+				Constraints: breaql.ConstraintChanges{"test_table.chk1": {"ALTER TABLE test_table DROP CHECK chk1;"}},
 			},
 			expectsErr: false,
 		},
@@ -76,7 +92,99 @@ func TestRunMySQL(t *testing.T) {
 			name: "AlterTableModifyColumn",
 			sql:  "ALTER TABLE test_table MODIFY column_name VARCHAR(255);",
 			want: breaql.BreakingChanges{
-				Tables: breaql.TableChanges{"test_table": {"ALTER TABLE test_table MODIFY column_name VARCHAR(255);"}},
+				Tables:  breaql.TableChanges{"test_table": {"ALTER TABLE test_table MODIFY column_name VARCHAR(255);"}},
+				Columns: breaql.ColumnChanges{"test_table.column_name": {"ALTER TABLE test_table MODIFY column_name VARCHAR(255);"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterTableRenameColumn",
+			sql:  "ALTER TABLE test_table RENAME COLUMN old_name TO new_name;",
+			want: breaql.BreakingChanges{
+				Tables:  breaql.TableChanges{"test_table": {"ALTER TABLE test_table RENAME COLUMN old_name TO new_name;"}},
+				Columns: breaql.ColumnChanges{"test_table.old_name": {"ALTER TABLE test_table RENAME COLUMN old_name TO new_name;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterTableChangeColumn",
+			sql:  "ALTER TABLE test_table CHANGE old_name new_name VARCHAR(255);",
+			want: breaql.BreakingChanges{
+				Tables:  breaql.TableChanges{"test_table": {"ALTER TABLE test_table CHANGE old_name new_name VARCHAR(255);"}},
+				Columns: breaql.ColumnChanges{"test_table.old_name": {"ALTER TABLE test_table CHANGE old_name new_name VARCHAR(255);"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterTableRenameTable",
+			sql:  "ALTER TABLE test_table_old RENAME TO test_table_new;",
+			want: breaql.BreakingChanges{
+				Tables: breaql.TableChanges{"test_table_old": {"ALTER TABLE test_table_old RENAME TO test_table_new;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterTableRenameIndex",
+			sql:  "ALTER TABLE test_table RENAME INDEX old_index TO new_index;",
+			want: breaql.BreakingChanges{
+				Tables:      breaql.TableChanges{"test_table": {"ALTER TABLE test_table RENAME INDEX old_index TO new_index;"}},
+				Constraints: breaql.ConstraintChanges{"test_table.old_index": {"ALTER TABLE test_table RENAME INDEX old_index TO new_index;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropView",
+			sql:  "DROP VIEW test_view;",
+			want: breaql.BreakingChanges{
+				Views: breaql.ViewChanges{"test_view": {"DROP VIEW test_view;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "CreateOrReplaceView",
+			sql:  "CREATE OR REPLACE VIEW test_view AS SELECT id FROM test_table;",
+			want: breaql.BreakingChanges{
+				Views: breaql.ViewChanges{"test_view": {"CREATE OR REPLACE VIEW test_view AS SELECT id FROM test_table;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropSequence",
+			sql:  "DROP SEQUENCE test_db.test_seq;",
+			want: breaql.BreakingChanges{
+				Sequences: breaql.SequenceChanges{"test_db.test_seq": {"DROP SEQUENCE test_db.test_seq;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropProcedure",
+			sql:  "DROP PROCEDURE IF EXISTS test_proc;",
+			want: breaql.BreakingChanges{
+				Routines: breaql.RoutineChanges{"test_proc": {"DROP PROCEDURE IF EXISTS test_proc;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropFunction",
+			sql:  "DROP FUNCTION test_func;",
+			want: breaql.BreakingChanges{
+				Routines: breaql.RoutineChanges{"test_func": {"DROP FUNCTION test_func;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropEvent",
+			sql:  "DROP EVENT IF EXISTS `test_db`.`test_event`;",
+			want: breaql.BreakingChanges{
+				Routines: breaql.RoutineChanges{"test_db.test_event": {"DROP EVENT IF EXISTS `test_db`.`test_event`;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropTrigger",
+			sql:  "DROP TRIGGER test_trigger;",
+			want: breaql.BreakingChanges{
+				Triggers: breaql.TriggerChanges{"test_trigger": {"DROP TRIGGER test_trigger;"}},
 			},
 			expectsErr: false,
 		},
@@ -94,6 +202,7 @@ func TestRunMySQL(t *testing.T) {
 			want: breaql.BreakingChanges{
 				Tables:    breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP COLUMN id;"}},
 				Databases: breaql.DatabaseChanges{"test_db": {"DROP DATABASE test_db;"}},
+				Columns:   breaql.ColumnChanges{"test_table.id": {"ALTER TABLE test_table DROP COLUMN id;"}},
 			},
 			expectsErr: false,
 		},
@@ -106,6 +215,10 @@ func TestRunMySQL(t *testing.T) {
 				ALTER TABLE test_table ADD INDEX idx_new_column (new_column);`,
 			want: breaql.BreakingChanges{
 				Tables: breaql.TableChanges{"test_table": {"ALTER TABLE test_table DROP COLUMN id;", "ALTER TABLE test_table DROP COLUMN new_column;"}},
+				Columns: breaql.ColumnChanges{
+					"test_table.id":         {"ALTER TABLE test_table DROP COLUMN id;"},
+					"test_table.new_column": {"ALTER TABLE test_table DROP COLUMN new_column;"},
+				},
 			},
 			expectsErr: false,
 		},
@@ -118,6 +231,7 @@ func TestRunMySQL(t *testing.T) {
 
 	opts := []cmp.Option{
 		cmpopts.EquateEmpty(),
+		cmpopts.IgnoreFields(breaql.BreakingChanges{}, "Findings"),
 	}
 
 	for _, tt := range tests {
@@ -134,3 +248,199 @@ func TestRunMySQL(t *testing.T) {
 		})
 	}
 }
+
+func TestRunMySQL_Findings(t *testing.T) {
+	sql := `CREATE TABLE test_table (id INT PRIMARY KEY);
+-- drop the legacy columns
+ALTER TABLE test_table DROP COLUMN a, DROP COLUMN b;
+DROP TABLE test_db.other_table;`
+
+	want := []breaql.Finding{
+		{
+			RuleID:     "mysql/drop-column",
+			Severity:   breaql.SeverityError,
+			Category:   breaql.CategoryBreaking,
+			ObjectKind: breaql.ObjectKindTable,
+			Object:     "test_table",
+			Column:     "a",
+			Statement:  "-- drop the legacy columns\nALTER TABLE test_table DROP COLUMN a, DROP COLUMN b;",
+			Position:   breaql.Position{Offset: 73, Line: 3, Column: 1},
+			End:        breaql.Position{Offset: 125, Line: 3, Column: 53},
+			Message:    "column a is dropped from table test_table",
+		},
+		{
+			RuleID:     "mysql/drop-column",
+			Severity:   breaql.SeverityError,
+			Category:   breaql.CategoryBreaking,
+			ObjectKind: breaql.ObjectKindTable,
+			Object:     "test_table",
+			Column:     "b",
+			Statement:  "-- drop the legacy columns\nALTER TABLE test_table DROP COLUMN a, DROP COLUMN b;",
+			Position:   breaql.Position{Offset: 73, Line: 3, Column: 1},
+			End:        breaql.Position{Offset: 125, Line: 3, Column: 53},
+			Message:    "column b is dropped from table test_table",
+		},
+		{
+			RuleID:     "mysql/drop-table",
+			Severity:   breaql.SeverityError,
+			Category:   breaql.CategoryBreaking,
+			ObjectKind: breaql.ObjectKindTable,
+			Object:     "test_db.other_table",
+			Statement:  "DROP TABLE test_db.other_table;",
+			Position:   breaql.Position{Offset: 126, Line: 4, Column: 1},
+			End:        breaql.Position{Offset: 157, Line: 4, Column: 32},
+			Message:    "table test_db.other_table is dropped",
+		},
+	}
+
+	got, err := breaql.RunMySQL(sql)
+	assert.NoError(t, err)
+	if diff := cmp.Diff(want, got.Findings); diff != "" {
+		t.Errorf("RunMySQL() findings mismatch (-want +got):\n%s", diff)
+	}
+	assert.Equal(t, []string{"-- drop the legacy columns\nALTER TABLE test_table DROP COLUMN a, DROP COLUMN b;"}, got.Tables.Statements("test_table"))
+}
+
+func TestRunMySQL_Renames(t *testing.T) {
+	got, err := breaql.RunMySQL(`RENAME TABLE a TO b, c TO d;
+		ALTER TABLE users RENAME COLUMN name TO full_name, RENAME INDEX idx_name TO idx_full_name, RENAME TO members;
+		ALTER TABLE members CHANGE email mail VARCHAR(100);
+		ALTER TABLE members CHANGE mail mail VARCHAR(200);`)
+	assert.NoError(t, err)
+
+	want := []string{
+		"mysql/rename-table a -> b",
+		"mysql/rename-table c -> d",
+		"mysql/rename-column users.name -> full_name",
+		"mysql/rename-index users.idx_name -> idx_full_name",
+		"mysql/rename-table users -> members",
+		"mysql/rename-column members.email -> mail",
+		"mysql/modify-column members.email",
+		"mysql/modify-column members.mail",
+	}
+	if diff := cmp.Diff(want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+		s := f.RuleID + " " + strings.Join(lo.Compact([]string{f.Object, f.Column, f.Constraint}), ".")
+		if f.NewName != "" {
+			s += " -> " + f.NewName
+		}
+		return s
+	})); diff != "" {
+		t.Errorf("RunMySQL() findings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunMySQL_AddConstraints(t *testing.T) {
+	got, err := breaql.RunMySQL(`ALTER TABLE users ADD UNIQUE KEY uniq_email (email), ADD UNIQUE (nickname), ADD INDEX idx_name (name);
+		ALTER TABLE users ADD CONSTRAINT chk_age CHECK (age >= 0), ADD CONSTRAINT chk_score CHECK (score >= 0) NOT ENFORCED;
+		ALTER TABLE posts ADD CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id);
+		CREATE UNIQUE INDEX uniq_code ON users (code);
+		CREATE INDEX idx_code ON users (code);`)
+	assert.NoError(t, err)
+
+	want := []string{
+		"mysql/add-unique-constraint users.uniq_email",
+		"mysql/add-unique-constraint users",
+		"mysql/add-check-constraint users.chk_age",
+		"mysql/add-foreign-key posts.fk_user",
+		"mysql/add-unique-constraint users.uniq_code",
+	}
+	if diff := cmp.Diff(want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+		return f.RuleID + " " + strings.Join(lo.Compact([]string{f.Object, f.Constraint}), ".")
+	})); diff != "" {
+		t.Errorf("RunMySQL() findings mismatch (-want +got):\n%s", diff)
+	}
+	assert.Equal(t, "unique key on (nickname) is added to table users, which rejects the writes of duplicate values and fails if the existing rows have duplicates",
+		got.Findings[1].Message)
+}
+
+func TestRunMySQL_DropCheckMatchesDiff(t *testing.T) {
+	oldSchema, err := breaql.LoadMySQLSchema("CREATE TABLE users (id INT PRIMARY KEY, age INT, CONSTRAINT chk_age CHECK (age >= 0));")
+	require.NoError(t, err)
+	newSchema, err := breaql.LoadMySQLSchema("CREATE TABLE users (id INT PRIMARY KEY, age INT);")
+	require.NoError(t, err)
+	diff, err := breaql.Diff(oldSchema, newSchema)
+	require.NoError(t, err)
+
+	for _, sql := range []string{"ALTER TABLE users DROP CHECK chk_age;", "ALTER TABLE users DROP CONSTRAINT chk_age;"} {
+		got, err := breaql.RunMySQL(sql)
+		require.NoError(t, err)
+		require.Len(t, got.Findings, 1)
+		assert.Equal(t, diff.Findings[0].RuleID, got.Findings[0].RuleID, sql)
+		assert.Equal(t, diff.Findings[0].Message, got.Findings[0].Message, sql)
+		assert.Equal(t, breaql.ConstraintChanges{"users.chk_age": {sql}}, got.Constraints, sql)
+	}
+}
+
+func TestRunMySQL_DuplicateObjects(t *testing.T) {
+	got, err := breaql.RunMySQL("DROP TABLE a, a;\nDROP TABLE a;")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DROP TABLE a, a;", "DROP TABLE a;"}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.Statement }))
+	assert.Equal(t, breaql.TableChanges{"a": {"DROP TABLE a, a;", "DROP TABLE a;"}}, got.Tables)
+}
+
+func TestRunMySQL_Partitions(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs and partitions of the findings
+	}{
+		{name: "DropPartition", sql: "ALTER TABLE logs DROP PARTITION p0, p1;", want: []string{"mysql/drop-partition p0,p1"}},
+		{name: "TruncatePartition", sql: "ALTER TABLE logs TRUNCATE PARTITION p0;", want: []string{"mysql/truncate-partition p0"}},
+		{name: "TruncateAllPartitions", sql: "ALTER TABLE logs TRUNCATE PARTITION ALL;", want: []string{"mysql/truncate-partition "}},
+		{
+			name: "ReorganizePartition",
+			sql:  "ALTER TABLE logs REORGANIZE PARTITION p0, p1 INTO (PARTITION p01 VALUES LESS THAN (2024));",
+			want: []string{"mysql/reorganize-partition p0,p1"},
+		},
+		{name: "CoalescePartition", sql: "ALTER TABLE logs COALESCE PARTITION 2;", want: []string{"mysql/coalesce-partition "}},
+		{name: "RemovePartitioning", sql: "ALTER TABLE logs REMOVE PARTITIONING;", want: []string{"mysql/remove-partitioning "}},
+		{
+			name: "ExchangePartition",
+			sql:  "ALTER TABLE logs EXCHANGE PARTITION p0 WITH TABLE logs_archive;",
+			want: []string{"mysql/exchange-partition p0"},
+		},
+		{name: "AddPartition", sql: "ALTER TABLE logs ADD PARTITION (PARTITION p9 VALUES LESS THAN (2030));"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunMySQL(tt.sql)
+			assert.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+				return f.RuleID + " " + strings.Join(f.Partitions, ",")
+			}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunMySQL() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	got, err := breaql.RunMySQL("ALTER TABLE logs EXCHANGE PARTITION p0 WITH TABLE logs_archive WITHOUT VALIDATION;")
+	assert.NoError(t, err)
+	assert.Equal(t, "rows in partition p0 of table logs are swapped with table logs_archive without validation", got.Findings[0].Message)
+}
+
+func TestRunMySQL_UnsupportedDrops(t *testing.T) {
+	sql := `CREATE VIEW v AS SELECT ';DROP TRIGGER x;' AS s;
+DROP TABLE a; -- breaql:ignore mysql/drop-table
+-- breaql:ignore mysql/drop-trigger reason="replaced by the application"
+DROP TRIGGER trg_a;
+DROP TABLE b;
+/* cleanup */ DROP FUNCTION f1; DROP EVENT e1`
+
+	got, err := breaql.RunMySQL(sql)
+	assert.NoError(t, err)
+
+	want := []string{
+		"mysql/drop-table b 5:1",
+		"mysql/drop-function f1 6:15",
+		"mysql/drop-event e1 6:33",
+	}
+	format := func(f breaql.Finding, _ int) string {
+		return fmt.Sprintf("%s %s %d:%d", f.RuleID, f.Object, f.Position.Line, f.Position.Column)
+	}
+	if diff := cmp.Diff(want, lo.Map(got.Findings, format)); diff != "" {
+		t.Errorf("RunMySQL() findings mismatch (-want +got):\n%s", diff)
+	}
+	assert.Equal(t, []string{"mysql/drop-table a 2:1", "mysql/drop-trigger trg_a 4:1"}, lo.Map(got.Suppressed, format))
+	assert.Equal(t, "DROP EVENT e1", got.Findings[2].Statement)
+}
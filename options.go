@@ -0,0 +1,84 @@
+package breaql
+
+import (
+	"path"
+	"slices"
+)
+
+// Options customizes the analysis of RunMySQLWithOptions and RunPostgreSQLWithOptions.
+// The zero value is the default used by RunMySQL and RunPostgreSQL.
+//
+// Rule and object patterns use the syntax of path.Match, e.g. "mysql/drop-*" or "public.tmp_*".
+type Options struct {
+	// RequireSuppressionReason makes breaql:ignore comments without a reason ineffective.
+	RequireSuppressionReason bool
+
+	// EnabledRules limits the findings to the rules matching any of the patterns. Empty means all the rules.
+	EnabledRules []string
+	// DisabledRules drops the findings of the rules matching any of the patterns.
+	DisabledRules []string
+	// Severities overrides the severities of the findings by rule ID.
+	Severities map[string]Severity
+
+	// AllowedObjects drops the findings on the objects whose qualified names match any of the patterns,
+	// i.e. the objects that are allowed to be broken.
+	AllowedObjects []string
+	// DeniedObjects keeps the findings on the matching objects even if they match AllowedObjects.
+	DeniedObjects []string
+
+	// OperationalRisks also reports the statements that may cause an outage while running,
+	// such as PostgreSQL statements holding a lock that blocks queries while they rewrite or scan a table.
+	// See CategoryOperational.
+	OperationalRisks bool
+	// MySQLVersion is the target MySQL version such as "5.7", "8.0" or "8.0.28", which decides the algorithms
+	// of ALTER TABLE reported as operational risks. A version without the patch number stands for its latest patch release.
+	// Empty means DefaultMySQLVersion.
+	MySQLVersion string
+
+	// Baseline is the schema before the analyzed statements are applied, e.g. loaded by LoadMySQLSchema or LoadPostgreSQLSchema.
+	// With it, changes to column definitions are classified instead of being reported as possibly breaking.
+	Baseline *Schema
+}
+
+// analysis collects the findings of a run according to the options.
+type analysis struct {
+	opts    Options
+	changes BreakingChanges
+	schema  *Schema // the baseline with the statements analyzed so far applied
+}
+
+func newAnalysis(opts Options) *analysis {
+	return &analysis{opts: opts, changes: NewBreakingChanges(), schema: opts.Baseline.clone()}
+}
+
+func (a *analysis) add(f Finding) {
+	if !a.opts.ruleEnabled(f.RuleID) || a.opts.objectAllowed(f.Object) {
+		return
+	}
+	if sev, ok := a.opts.Severities[f.RuleID]; ok {
+		f.Severity = sev
+	}
+	if f.Suppression != nil && f.Suppression.Reason == "" && a.opts.RequireSuppressionReason {
+		f.Suppression = nil
+		f.Message += " (breaql:ignore without a reason is not accepted)"
+	}
+	a.changes.add(f)
+}
+
+func (o Options) ruleEnabled(ruleID string) bool {
+	if len(o.EnabledRules) > 0 && !matchAny(o.EnabledRules, ruleID) {
+		return false
+	}
+	return !matchAny(o.DisabledRules, ruleID)
+}
+
+func (o Options) objectAllowed(object string) bool {
+	return matchAny(o.AllowedObjects, object) && !matchAny(o.DeniedObjects, object)
+}
+
+func matchAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
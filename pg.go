@@ -1,8 +1,8 @@
 package breaql
 
 import (
+	"fmt"
 	"log/slog"
-	"slices"
 	"strings"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
@@ -10,111 +10,503 @@ import (
 
 // RunPostgreSQL parses the given DDL statements and returns the breaking ones.
 func RunPostgreSQL(sql string) (BreakingChanges, error) {
-	sql = strings.TrimSpace(sql)
-	if !strings.HasSuffix(sql, ";") {
-		sql += ";"
-	}
+	return RunPostgreSQLWithOptions(sql, Options{})
+}
 
-	tree, err := pg_query.Parse(sql)
+// RunPostgreSQLWithOptions is like RunPostgreSQL but customizes the analysis with opts.
+func RunPostgreSQLWithOptions(sql string, opts Options) (BreakingChanges, error) {
+	tree, stmts, err := parsePostgreSQL(sql)
 	if err != nil {
-		return BreakingChanges{}, &ParseError{original: err, Message: err.Error(), funcName: "pg_query.Parse"}
+		return BreakingChanges{}, err
 	}
 
-	changes := NewBreakingChanges()
+	a := newAnalysis(opts)
 
-	for _, rawStmt := range tree.GetStmts() {
-		start := rawStmt.GetStmtLocation()
-		end := start + rawStmt.GetStmtLen()
-		stmtText := strings.TrimSpace(sql[start:end]) + ";"
-		slog.Info("processing stmt", slog.String("stmt", stmtText))
+	for i, rawStmt := range tree.GetStmts() {
+		stmt := stmts[i]
+		slog.Info("processing stmt", slog.String("stmt", stmt.text))
 
 		switch n := rawStmt.GetStmt().GetNode().(type) {
 		case *pg_query.Node_DropdbStmt:
-			changes.Databases.add(n.DropdbStmt.GetDbname(), stmtText)
+			name := n.DropdbStmt.GetDbname()
+			a.add(stmt.finding("pg/drop-database", ObjectKindDatabase, name,
+				fmt.Sprintf("database %s is dropped", name)))
 
 		case *pg_query.Node_DropStmt:
-			switch n.DropStmt.RemoveType {
-			case pg_query.ObjectType_OBJECT_SCHEMA:
-				for _, obj := range n.DropStmt.GetObjects() {
-					if str := obj.GetString_(); str != nil {
-						changes.Schemas.add(str.GetSval(), stmtText)
-					}
-				}
-
-			case pg_query.ObjectType_OBJECT_TABLE:
-				for _, obj := range n.DropStmt.GetObjects() {
-					if list := obj.GetList(); list != nil && len(list.GetItems()) > 0 {
-						var parts []string
-						for _, item := range list.GetItems() {
-							if str := item.GetString_(); str != nil {
-								parts = append(parts, str.GetSval())
-							}
-						}
-						table := strings.Join(parts, ".")
-						changes.Tables.add(table, stmtText)
-					}
-				}
-
-			case pg_query.ObjectType_OBJECT_INDEX:
-				for _, obj := range n.DropStmt.GetObjects() {
-					if list := obj.GetList(); list != nil && len(list.GetItems()) > 0 {
-						var parts []string
-						for _, item := range list.GetItems() {
-							if str := item.GetString_(); str != nil {
-								parts = append(parts, str.GetSval())
-							}
-						}
-						index := strings.Join(parts, ".")
-						changes.Indexes.add(index, stmtText)
-					}
-				}
+			for _, f := range dropStmtFindings(stmt, a.schema, n.DropStmt) {
+				a.add(f)
 			}
 
 		case *pg_query.Node_TruncateStmt:
 			for _, rel := range n.TruncateStmt.GetRelations() {
 				if rv := rel.GetRangeVar(); rv != nil {
-					table := rv.GetRelname()
-					if schema := rv.GetSchemaname(); schema != "" {
-						table = schema + "." + table
-					}
-					changes.Tables.add(table, stmtText)
+					table := pgRangeVarName(rv)
+					a.add(stmt.finding("pg/truncate-table", ObjectKindTable, table,
+						fmt.Sprintf("all rows in table %s are deleted", table)))
 				}
 			}
 
+		case *pg_query.Node_DeleteStmt, *pg_query.Node_UpdateStmt:
+			for _, f := range pgDMLFindings(stmt, rawStmt.GetStmt()) {
+				a.add(f)
+			}
+
 		case *pg_query.Node_RenameStmt:
 			if rv := n.RenameStmt.GetRelation(); rv != nil {
-				table := rv.GetRelname()
-				if schema := rv.GetSchemaname(); schema != "" {
-					table = schema + "." + table
+				if f, ok := renameStmtFinding(stmt, pgRangeVarName(rv), n.RenameStmt); ok {
+					a.add(f)
 				}
-				changes.Tables.add(table, stmtText)
+			} else if f, ok := renameTypeFinding(stmt, n.RenameStmt); ok {
+				a.add(f)
+			}
+
+		case *pg_query.Node_AlterEnumStmt:
+			// Adding a value is not breaking, while renaming one breaks the writers and readers of the old value.
+			if old := n.AlterEnumStmt.GetOldVal(); old != "" {
+				typ := pgNames(n.AlterEnumStmt.GetTypeName())
+				f := stmt.finding("pg/rename-enum-value", ObjectKindType, typ,
+					fmt.Sprintf("value '%s' of enum type %s is renamed to '%s'", old, typ, n.AlterEnumStmt.GetNewVal()))
+				f.NewName = n.AlterEnumStmt.GetNewVal()
+				a.add(f)
+			}
+
+		case *pg_query.Node_AlterDomainStmt:
+			if f, ok := alterDomainFinding(stmt, n.AlterDomainStmt); ok {
+				a.add(f)
+			}
+
+		case *pg_query.Node_IndexStmt:
+			if n.IndexStmt.GetUnique() {
+				table := pgRangeVarName(n.IndexStmt.GetRelation())
+				index := n.IndexStmt.GetIdxname()
+				f := stmt.finding("pg/add-unique-constraint", ObjectKindTable, table,
+					fmt.Sprintf("unique index %s is created on table %s, which rejects the writes of duplicate values and fails if the existing rows have duplicates", index, table))
+				f.Constraint = index
+				a.add(f)
 			}
 
 		case *pg_query.Node_AlterTableStmt:
 			if rv := n.AlterTableStmt.GetRelation(); rv != nil {
-				if slices.ContainsFunc(n.AlterTableStmt.GetCmds(), isBreakingAlterTableCmd) {
-					table := rv.GetRelname()
-					if schema := rv.GetSchemaname(); schema != "" {
-						table = schema + "." + table
+				table := pgRangeVarName(rv)
+				t := a.schema.table(table)
+				for _, cmd := range n.AlterTableStmt.GetCmds() {
+					for _, f := range alterTableCmdFindings(stmt, table, t, cmd) {
+						a.add(f)
 					}
-					changes.Tables.add(table, stmtText)
 				}
 			}
 		}
+
+		if opts.OperationalRisks {
+			for _, f := range pgLockFindings(stmt, a.schema, rawStmt.GetStmt()) {
+				a.add(f)
+			}
+			for _, f := range pgUnbatchedDMLFindings(stmt, rawStmt.GetStmt()) {
+				a.add(f)
+			}
+		}
+
+		applyPostgreSQLStmt(a.schema, stmt, rawStmt.GetStmt())
+	}
+
+	return a.changes, nil
+}
+
+// parsePostgreSQL parses the given statements and locates them in src.
+func parsePostgreSQL(src string) (*pg_query.ParseResult, []statement, error) {
+	sql := strings.TrimSpace(src)
+	base := strings.Index(src, sql) // offset of the trimmed text in the original input
+	if !strings.HasSuffix(sql, ";") {
+		sql += ";"
+	}
+
+	tree, err := pg_query.Parse(sql)
+	if err != nil {
+		return nil, nil, &ParseError{original: err, Message: err.Error(), funcName: "pg_query.Parse"}
+	}
+
+	stmts := make([]statement, 0, len(tree.GetStmts()))
+	for _, rawStmt := range tree.GetStmts() {
+		start := int(rawStmt.GetStmtLocation())
+		end := start + int(rawStmt.GetStmtLen())
+		if rawStmt.GetStmtLen() == 0 {
+			end = len(sql)
+		}
+		stmt := newStatement(src, strings.TrimSuffix(sql[start:end], ";"), base+start)
+		stmt.text += ";"
+		stmts = append(stmts, stmt)
 	}
+	return tree, stmts, nil
+}
+
+// renameStmtFinding returns the finding on the renaming of a relation, or of a column, constraint, trigger or policy of one.
+func renameStmtFinding(stmt statement, relation string, rename *pg_query.RenameStmt) (Finding, bool) {
+	var f Finding
+	switch rename.GetRenameType() {
+	case pg_query.ObjectType_OBJECT_COLUMN:
+		f = stmt.finding("pg/rename-column", ObjectKindTable, relation,
+			fmt.Sprintf("column %s of table %s is renamed to %s", rename.GetSubname(), relation, rename.GetNewname()))
+		f.Column = rename.GetSubname()
+
+	case pg_query.ObjectType_OBJECT_TABCONSTRAINT:
+		f = stmt.finding("pg/rename-constraint", ObjectKindTable, relation,
+			fmt.Sprintf("constraint %s of table %s is renamed to %s", rename.GetSubname(), relation, rename.GetNewname()))
+		f.Constraint = rename.GetSubname()
+
+	case pg_query.ObjectType_OBJECT_INDEX:
+		f = stmt.finding("pg/rename-index", ObjectKindIndex, relation,
+			fmt.Sprintf("index %s is renamed to %s", relation, rename.GetNewname()))
+
+	case pg_query.ObjectType_OBJECT_ATTRIBUTE:
+		f = stmt.finding("pg/rename-type", ObjectKindType, relation,
+			fmt.Sprintf("attribute %s of type %s is renamed to %s", rename.GetSubname(), relation, rename.GetNewname()))
+		f.Column = rename.GetSubname()
+
+	case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_FOREIGN_TABLE:
+		f = stmt.finding("pg/rename-table", ObjectKindTable, relation,
+			fmt.Sprintf("%s %s is renamed to %s", pgRenamedNoun(rename), relation, rename.GetNewname()))
+
+	case pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+		f = stmt.finding("pg/rename-view", ObjectKindView, relation,
+			fmt.Sprintf("%s %s is renamed to %s", pgRenamedNoun(rename), relation, rename.GetNewname()))
+
+	case pg_query.ObjectType_OBJECT_SEQUENCE:
+		f = stmt.finding("pg/rename-sequence", ObjectKindSequence, relation,
+			fmt.Sprintf("sequence %s is renamed to %s", relation, rename.GetNewname()))
 
-	return changes, nil
+	case pg_query.ObjectType_OBJECT_TRIGGER:
+		// Triggers and policies are qualified by their tables as in DROP statements.
+		trigger := relation + "." + rename.GetSubname()
+		f = stmt.finding("pg/rename-trigger", ObjectKindTrigger, trigger,
+			fmt.Sprintf("trigger %s on table %s is renamed to %s", rename.GetSubname(), relation, rename.GetNewname()))
+
+	case pg_query.ObjectType_OBJECT_POLICY:
+		policy := relation + "." + rename.GetSubname()
+		f = stmt.finding("pg/rename-policy", ObjectKindPolicy, policy,
+			fmt.Sprintf("policy %s on table %s is renamed to %s", rename.GetSubname(), relation, rename.GetNewname()))
+
+	default:
+		return Finding{}, false
+	}
+	f.NewName = rename.GetNewname()
+	return f, true
+}
+
+// pgRenamedNoun returns the kind of the relation renamed by the given statement, e.g. "materialized view".
+func pgRenamedNoun(rename *pg_query.RenameStmt) string {
+	return pgDroppedObjects[rename.GetRenameType()].noun
+}
+
+// renameTypeFinding returns the finding on the renaming of a type or domain, or of a domain constraint.
+func renameTypeFinding(stmt statement, rename *pg_query.RenameStmt) (Finding, bool) {
+	typ := pgObjectName(rename.GetObject())
+	var f Finding
+	switch rename.GetRenameType() {
+	case pg_query.ObjectType_OBJECT_TYPE, pg_query.ObjectType_OBJECT_DOMAIN:
+		noun := "type"
+		if rename.GetRenameType() == pg_query.ObjectType_OBJECT_DOMAIN {
+			noun = "domain"
+		}
+		f = stmt.finding("pg/rename-type", ObjectKindType, typ,
+			fmt.Sprintf("%s %s is renamed to %s", noun, typ, rename.GetNewname()))
+
+	case pg_query.ObjectType_OBJECT_DOMCONSTRAINT:
+		f = stmt.finding("pg/rename-constraint", ObjectKindType, typ,
+			fmt.Sprintf("constraint %s of domain %s is renamed to %s", rename.GetSubname(), typ, rename.GetNewname()))
+		f.Constraint = rename.GetSubname()
+
+	default:
+		return Finding{}, false
+	}
+	f.NewName = rename.GetNewname()
+	return f, true
 }
 
-func isBreakingAlterTableCmd(cmd *pg_query.Node) bool {
-	switch c := cmd.GetNode().(type) {
-	case *pg_query.Node_AlterTableCmd:
-		switch c.AlterTableCmd.GetSubtype() {
-		case pg_query.AlterTableType_AT_DropColumn,
-			pg_query.AlterTableType_AT_DropConstraint,
-			pg_query.AlterTableType_AT_AlterColumnType:
-			return true
+// alterDomainFinding returns the finding on the given ALTER DOMAIN statement if it makes the domain stricter.
+func alterDomainFinding(stmt statement, alter *pg_query.AlterDomainStmt) (Finding, bool) {
+	domain := pgNames(alter.GetTypeName())
+	switch alter.GetSubtype() {
+	case "C": // ADD CONSTRAINT
+		cons := alter.GetDef().GetConstraint()
+		message := fmt.Sprintf("constraint %s is added to domain %s, which rejects the writes violating it", cons.GetConname(), domain)
+		if cons.GetSkipValidation() {
+			message += "; existing values are not validated until VALIDATE CONSTRAINT"
+		} else {
+			message += " and fails if an existing value violates it"
 		}
+		f := stmt.finding("pg/add-domain-constraint", ObjectKindType, domain, message)
+		f.Constraint = cons.GetConname()
+		return f, true
+
+	case "O": // SET NOT NULL
+		return stmt.finding("pg/domain-set-not-null", ObjectKindType, domain,
+			fmt.Sprintf("domain %s becomes NOT NULL, which rejects the writes of NULL and fails if an existing value is NULL", domain)), true
+	}
+	return Finding{}, false
+}
+
+// alterTableCmdFindings returns the findings for the given command if it is breaking.
+// t is the table in the baseline schema, or nil if unknown.
+func alterTableCmdFindings(stmt statement, table string, t *schemaTable, cmd *pg_query.Node) []Finding {
+	c := cmd.GetAlterTableCmd()
+	if c == nil {
+		return nil
+	}
+
+	switch c.GetSubtype() {
+	case pg_query.AlterTableType_AT_AddColumn:
+		def := c.GetDef().GetColumnDef()
+		if !pgRequiresValue(def) {
+			return nil
+		}
+		f := stmt.finding("pg/add-not-null-column", ObjectKindTable, table,
+			fmt.Sprintf("column %s is added to table %s as NOT NULL without a default, which fails unless the table is empty and rejects writers omitting it", def.GetColname(), table))
+		f.Column = def.GetColname()
+		return []Finding{f}
+
+	case pg_query.AlterTableType_AT_SetNotNull:
+		if old := t.column(c.GetName()); old != nil && old.notNull {
+			return nil
+		}
+		f := stmt.finding("pg/column-set-not-null", ObjectKindTable, table,
+			fmt.Sprintf("column %s of table %s becomes NOT NULL, which fails on existing NULL values and rejects writers omitting it", c.GetName(), table))
+		f.Column = c.GetName()
+		return []Finding{f}
+
+	case pg_query.AlterTableType_AT_ColumnDefault:
+		if c.GetDef() != nil {
+			return nil // SET DEFAULT
+		}
+		message := fmt.Sprintf("default of column %s of table %s is dropped, which rejects writers omitting it if the column is NOT NULL", c.GetName(), table)
+		if old := t.column(c.GetName()); old != nil {
+			if !old.notNull {
+				return nil
+			}
+			message = fmt.Sprintf("default of NOT NULL column %s of table %s is dropped, which rejects writers omitting it", c.GetName(), table)
+		}
+		f := stmt.finding("pg/drop-column-default", ObjectKindTable, table, message)
+		f.Column = c.GetName()
+		return []Finding{f}
+
+	case pg_query.AlterTableType_AT_AddConstraint:
+		if f, ok := pgAddConstraintFinding(stmt, table, c.GetDef().GetConstraint()); ok {
+			return []Finding{f}
+		}
+		return nil
+
+	case pg_query.AlterTableType_AT_DropColumn:
+		f := stmt.finding("pg/drop-column", ObjectKindTable, table,
+			fmt.Sprintf("column %s is dropped from table %s", c.GetName(), table))
+		f.Column = c.GetName()
+		findings := []Finding{f}
+		if c.GetBehavior() == pg_query.DropBehavior_DROP_CASCADE {
+			f = stmt.finding("pg/drop-cascade", ObjectKindTable, table,
+				fmt.Sprintf("column %s of table %s is dropped with CASCADE, which also drops the objects depending on it", c.GetName(), table))
+			f.Column = c.GetName()
+			findings = append(findings, f)
+		}
+		return findings
+
+	case pg_query.AlterTableType_AT_DropConstraint:
+		f := stmt.finding("pg/drop-constraint", ObjectKindTable, table,
+			fmt.Sprintf("constraint %s is dropped from table %s", c.GetName(), table))
+		f.Constraint = c.GetName()
+		findings := []Finding{f}
+		if c.GetBehavior() == pg_query.DropBehavior_DROP_CASCADE {
+			f = stmt.finding("pg/drop-cascade", ObjectKindTable, table,
+				fmt.Sprintf("constraint %s of table %s is dropped with CASCADE, which also drops the objects depending on it", c.GetName(), table))
+			f.Constraint = c.GetName()
+			findings = append(findings, f)
+		}
+		return findings
+
+	case pg_query.AlterTableType_AT_AlterColumnType:
+		def := c.GetDef().GetColumnDef()
+		if old := t.column(c.GetName()); old != nil {
+			return pgColumnFindings(stmt, table, old, pgColumnType(def.GetTypeName()), def.GetRawDefault() != nil)
+		}
+		f := stmt.finding("pg/alter-column-type", ObjectKindTable, table,
+			fmt.Sprintf("type of column %s of table %s is changed", c.GetName(), table))
+		f.Column = c.GetName()
+		return []Finding{f}
+	}
+	return nil
+}
+
+// pgAddConstraintFinding returns the finding on the given unique, exclusion, check or foreign key constraint added to the table.
+func pgAddConstraintFinding(stmt statement, table string, cons *pg_query.Constraint) (Finding, bool) {
+	var ruleID, message string
+	switch cons.GetContype() {
+	case pg_query.ConstrType_CONSTR_UNIQUE:
+		if cons.GetIndexname() != "" {
+			return Finding{}, false // the existing unique index already rejects the duplicates
+		}
+		ruleID, message = "pg/add-unique-constraint", fmt.Sprintf("%s is added to table %s, which rejects the writes of duplicate values and fails if the existing rows have duplicates",
+			constraintLabel("unique constraint", cons.GetConname(), pgKeyNames(cons.GetKeys())), table)
+
+	case pg_query.ConstrType_CONSTR_EXCLUSION:
+		ruleID, message = "pg/add-unique-constraint", fmt.Sprintf("%s is added to table %s, which rejects the writes conflicting with existing rows and fails if the existing rows conflict",
+			constraintLabel("exclusion constraint", cons.GetConname(), nil), table)
+
+	case pg_query.ConstrType_CONSTR_CHECK:
+		ruleID, message = "pg/add-check-constraint", fmt.Sprintf("%s is added to table %s, which rejects the writes violating it",
+			constraintLabel("check constraint", cons.GetConname(), nil), table)
+
+	case pg_query.ConstrType_CONSTR_FOREIGN:
+		ruleID, message = "pg/add-foreign-key", fmt.Sprintf("%s is added to table %s, which rejects the writes referencing missing rows of table %s",
+			constraintLabel("foreign key", cons.GetConname(), pgKeyNames(cons.GetFkAttrs())), table, pgRangeVarName(cons.GetPktable()))
+
+	default:
+		return Finding{}, false
+	}
+	// Only checks and foreign keys can skip validating the existing rows.
+	switch {
+	case ruleID == "pg/add-unique-constraint":
+	case cons.GetSkipValidation():
+		message += "; existing rows are not validated until VALIDATE CONSTRAINT, which should follow"
+	default:
+		message += " and fails if an existing row violates it; add it NOT VALID and run VALIDATE CONSTRAINT separately"
+	}
+	f := stmt.finding(ruleID, ObjectKindTable, table, message)
+	f.Constraint = cons.GetConname()
+	return f, true
+}
+
+// pgRequiresValue returns whether the writers must set the column defined by def, i.e. it is NOT NULL without a default.
+func pgRequiresValue(def *pg_query.ColumnDef) bool {
+	if pgSerial(def) {
+		return false
+	}
+	notNull := false
+	for _, cons := range def.GetConstraints() {
+		switch cons.GetConstraint().GetContype() {
+		case pg_query.ConstrType_CONSTR_NOTNULL, pg_query.ConstrType_CONSTR_PRIMARY:
+			notNull = true
+		case pg_query.ConstrType_CONSTR_NULL, pg_query.ConstrType_CONSTR_DEFAULT, pg_query.ConstrType_CONSTR_IDENTITY, pg_query.ConstrType_CONSTR_GENERATED:
+			return false
+		}
+	}
+	return notNull
+}
+
+// pgSerial returns whether the column defined by def is of a serial type, which is filled from a sequence.
+func pgSerial(def *pg_query.ColumnDef) bool {
+	names := def.GetTypeName().GetNames()
+	return len(names) > 0 && strings.HasSuffix(names[len(names)-1].GetString_().GetSval(), "serial")
+}
+
+// pgDropped describes the findings on the objects of a kind dropped by DROP statements.
+type pgDropped struct {
+	rule string
+	kind ObjectKind
+	noun string
+}
+
+var pgDroppedObjects = map[pg_query.ObjectType]pgDropped{
+	pg_query.ObjectType_OBJECT_SCHEMA:        {"pg/drop-schema", ObjectKindSchema, "schema"},
+	pg_query.ObjectType_OBJECT_TABLE:         {"pg/drop-table", ObjectKindTable, "table"},
+	pg_query.ObjectType_OBJECT_FOREIGN_TABLE: {"pg/drop-foreign-table", ObjectKindTable, "foreign table"},
+	pg_query.ObjectType_OBJECT_VIEW:          {"pg/drop-view", ObjectKindView, "view"},
+	pg_query.ObjectType_OBJECT_MATVIEW:       {"pg/drop-materialized-view", ObjectKindView, "materialized view"},
+	pg_query.ObjectType_OBJECT_INDEX:         {"pg/drop-index", ObjectKindIndex, "index"},
+	pg_query.ObjectType_OBJECT_SEQUENCE:      {"pg/drop-sequence", ObjectKindSequence, "sequence"},
+	pg_query.ObjectType_OBJECT_TYPE:          {"pg/drop-type", ObjectKindType, "type"},
+	pg_query.ObjectType_OBJECT_DOMAIN:        {"pg/drop-domain", ObjectKindType, "domain"},
+	pg_query.ObjectType_OBJECT_TRIGGER:       {"pg/drop-trigger", ObjectKindTrigger, "trigger"},
+	pg_query.ObjectType_OBJECT_POLICY:        {"pg/drop-policy", ObjectKindPolicy, "policy"},
+	pg_query.ObjectType_OBJECT_FUNCTION:      {"pg/drop-function", ObjectKindRoutine, "function"},
+	pg_query.ObjectType_OBJECT_PROCEDURE:     {"pg/drop-procedure", ObjectKindRoutine, "procedure"},
+	pg_query.ObjectType_OBJECT_ROUTINE:       {"pg/drop-function", ObjectKindRoutine, "routine"},
+	pg_query.ObjectType_OBJECT_EXTENSION:     {"pg/drop-extension", ObjectKindExtension, "extension"},
+}
+
+// dropStmtFindings returns the findings on the objects dropped by the given statement.
+// With CASCADE, the objects in the schema that depend on them are reported as well.
+func dropStmtFindings(stmt statement, schema *Schema, drop *pg_query.DropStmt) []Finding {
+	dropped, ok := pgDroppedObjects[drop.GetRemoveType()]
+	if !ok {
+		return nil
+	}
+	cascade := drop.GetBehavior() == pg_query.DropBehavior_DROP_CASCADE
+
+	var findings []Finding
+	for _, obj := range drop.GetObjects() {
+		name := pgObjectName(obj)
+		if name == "" {
+			continue
+		}
+		findings = append(findings, stmt.finding(dropped.rule, dropped.kind, name,
+			fmt.Sprintf("%s %s is dropped", dropped.noun, name)))
+		if cascade {
+			findings = append(findings, stmt.finding("pg/drop-cascade", dropped.kind, name,
+				fmt.Sprintf("%s %s is dropped with CASCADE, which also drops the objects depending on it", dropped.noun, name)))
+		}
+	}
+
+	for _, c := range pgCascadedObjects(schema, drop) {
+		f := stmt.finding("pg/drop-cascade", c.kind, c.object, c.message)
+		f.Column = c.column
+		f.Constraint = c.constraint
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// pgObjectName returns the qualified name of the object given to DROP statements.
+// Triggers and policies are qualified by their tables, and functions are followed by their argument types.
+func pgObjectName(obj *pg_query.Node) string {
+	switch {
+	case obj.GetString_() != nil:
+		return obj.GetString_().GetSval()
+
+	case obj.GetTypeName() != nil:
+		return pgNames(obj.GetTypeName().GetNames())
+
+	case obj.GetObjectWithArgs() != nil:
+		fn := obj.GetObjectWithArgs()
+		name := pgNames(fn.GetObjname())
+		if fn.GetArgsUnspecified() {
+			return name
+		}
+		args := make([]string, 0, len(fn.GetObjargs()))
+		for _, arg := range fn.GetObjargs() {
+			args = append(args, strings.ToLower(pgColumnType(arg.GetTypeName()).String()))
+		}
+		return name + "(" + strings.Join(args, ", ") + ")"
+
+	default:
+		return pgQualifiedName(obj)
+	}
+}
+
+// pgQualifiedName joins the name parts of the given List node with dots.
+func pgQualifiedName(obj *pg_query.Node) string {
+	return pgNames(obj.GetList().GetItems())
+}
+
+// pgKeyNames returns the column names listed in a constraint.
+func pgKeyNames(keys []*pg_query.Node) []string {
+	var names []string
+	for _, key := range keys {
+		names = append(names, key.GetString_().GetSval())
+	}
+	return names
+}
+
+// pgNames joins the given String nodes with dots.
+func pgNames(items []*pg_query.Node) string {
+	var parts []string
+	for _, item := range items {
+		if str := item.GetString_(); str != nil {
+			parts = append(parts, str.GetSval())
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func pgRangeVarName(rv *pg_query.RangeVar) string {
+	if schema := rv.GetSchemaname(); schema != "" {
+		return schema + "." + rv.GetRelname()
 	}
-	return false
+	return rv.GetRelname()
 }
@@ -0,0 +1,169 @@
+package breaql
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"github.com/samber/lo"
+)
+
+// pgDMLFindings returns the findings on the given DELETE or UPDATE statement if it changes every row of a table,
+// i.e. it has no WHERE clause or an always-true one and is not restricted by USING or FROM.
+func pgDMLFindings(stmt statement, node *pg_query.Node) []Finding {
+	switch {
+	case node.GetDeleteStmt() != nil:
+		n := node.GetDeleteStmt()
+		if len(n.GetUsingClause()) > 0 || !pgAllRows(n.GetWhereClause()) {
+			return nil
+		}
+		table := pgRangeVarName(n.GetRelation())
+		return []Finding{stmt.finding("pg/delete-all-rows", ObjectKindTable, table,
+			fmt.Sprintf("DELETE %s deletes the rows of table %s regardless of their values", pgWhereClause(n.GetWhereClause()), table))}
+
+	case node.GetUpdateStmt() != nil:
+		n := node.GetUpdateStmt()
+		if len(n.GetFromClause()) > 0 || !pgAllRows(n.GetWhereClause()) {
+			return nil
+		}
+		table := pgRangeVarName(n.GetRelation())
+		columns := lo.Map(n.GetTargetList(), func(target *pg_query.Node, _ int) string { return target.GetResTarget().GetName() })
+		return []Finding{stmt.finding("pg/update-all-rows", ObjectKindTable, table,
+			fmt.Sprintf("UPDATE %s overwrites %s of the rows of table %s regardless of their values", pgWhereClause(n.GetWhereClause()), strings.Join(lo.Uniq(columns), ", "), table))}
+	}
+	return nil
+}
+
+// pgUnbatchedDMLFindings returns the operational risks of the given statement if it may change many rows at once:
+// UPDATE and DELETE unless the WHERE clause picks rows by key or by a LIMITed subquery, and INSERT ... SELECT from tables without LIMIT.
+func pgUnbatchedDMLFindings(stmt statement, node *pg_query.Node) []Finding {
+	var table, message string
+	switch {
+	case node.GetDeleteStmt() != nil:
+		n := node.GetDeleteStmt()
+		if pgPicksByKey(n.GetWhereClause()) || pgHasLimit(n.GetWhereClause()) {
+			return nil
+		}
+		table = pgRangeVarName(n.GetRelation())
+		message = "rows of table %s are deleted by a single statement without a batch limit, which locks the rows until it commits and leaves as many dead tuples"
+
+	case node.GetUpdateStmt() != nil:
+		n := node.GetUpdateStmt()
+		if pgPicksByKey(n.GetWhereClause()) || pgHasLimit(n.GetWhereClause()) {
+			return nil
+		}
+		table = pgRangeVarName(n.GetRelation())
+		message = "rows of table %s are updated by a single statement without a batch limit, which locks the rows until it commits and leaves as many dead tuples"
+
+	case node.GetInsertStmt() != nil:
+		n := node.GetInsertStmt()
+		sel := n.GetSelectStmt().GetSelectStmt()
+		if len(sel.GetFromClause()) == 0 || sel.GetLimitCount() != nil {
+			return nil
+		}
+		table = pgRangeVarName(n.GetRelation())
+		message = "rows of table %s are backfilled by a single INSERT ... SELECT without LIMIT, which holds the locks on the inserted rows until it commits"
+		if n.GetOnConflictClause().GetAction() == pg_query.OnConflictAction_ONCONFLICT_UPDATE {
+			message = "rows of table %s are backfilled by a single INSERT ... SELECT ... ON CONFLICT DO UPDATE without LIMIT, which overwrites the existing rows, locks them until it commits and leaves as many dead tuples"
+		}
+
+	default:
+		return nil
+	}
+
+	return []Finding{stmt.finding("pg/unbatched-dml", ObjectKindTable, table, fmt.Sprintf(message, table))}
+}
+
+func pgWhereClause(where *pg_query.Node) string {
+	if where == nil {
+		return "without a WHERE clause"
+	}
+	return "with an always-true WHERE clause"
+}
+
+// pgAllRows returns whether the WHERE clause matches every row, i.e. it is missing or always true.
+func pgAllRows(where *pg_query.Node) bool {
+	return where == nil || pgAlwaysTrue(where)
+}
+
+// pgAlwaysTrue returns whether the expression is trivially true, such as true or 1 = 1.
+func pgAlwaysTrue(expr *pg_query.Node) bool {
+	switch {
+	case expr.GetAConst() != nil:
+		return expr.GetAConst().GetBoolval().GetBoolval()
+	case expr.GetBoolExpr() != nil:
+		args := expr.GetBoolExpr().GetArgs()
+		switch expr.GetBoolExpr().GetBoolop() {
+		case pg_query.BoolExprType_OR_EXPR:
+			return lo.SomeBy(args, pgAlwaysTrue)
+		case pg_query.BoolExprType_AND_EXPR:
+			return lo.EveryBy(args, pgAlwaysTrue)
+		}
+	case expr.GetAExpr() != nil:
+		e := expr.GetAExpr()
+		if e.GetKind() != pg_query.A_Expr_Kind_AEXPR_OP || !pgOperatorIs(e.GetName(), "=", ">=", "<=") {
+			return false
+		}
+		l, r := e.GetLexpr().GetAConst(), e.GetRexpr().GetAConst()
+		return l != nil && r != nil && !l.GetIsnull() && pgConstValue(l) == pgConstValue(r)
+	}
+	return false
+}
+
+// pgOperatorIs returns whether the operator name is one of the given operators.
+func pgOperatorIs(name []*pg_query.Node, ops ...string) bool {
+	return len(name) == 1 && lo.Contains(ops, name[0].GetString_().GetSval())
+}
+
+// pgConstValue returns the given constant as a string.
+func pgConstValue(c *pg_query.A_Const) string {
+	switch {
+	case c.GetIval() != nil:
+		return fmt.Sprint(c.GetIval().GetIval())
+	case c.GetFval() != nil:
+		return c.GetFval().GetFval()
+	case c.GetBoolval() != nil:
+		return fmt.Sprint(c.GetBoolval().GetBoolval())
+	case c.GetSval() != nil:
+		return "'" + c.GetSval().GetSval() + "'"
+	case c.GetBsval() != nil:
+		return c.GetBsval().GetBsval()
+	}
+	return ""
+}
+
+// pgPicksByKey returns whether the WHERE clause picks rows by values of a column, e.g. id = 1 or id IN (1, 2),
+// which is assumed to match a few rows.
+func pgPicksByKey(where *pg_query.Node) bool {
+	switch {
+	case where.GetBoolExpr() != nil:
+		return where.GetBoolExpr().GetBoolop() == pg_query.BoolExprType_AND_EXPR && lo.SomeBy(where.GetBoolExpr().GetArgs(), pgPicksByKey)
+	case where.GetAExpr() != nil:
+		e := where.GetAExpr()
+		if e.GetLexpr().GetColumnRef() == nil {
+			return false
+		}
+		switch e.GetKind() {
+		case pg_query.A_Expr_Kind_AEXPR_OP:
+			return pgOperatorIs(e.GetName(), "=") && e.GetRexpr().GetAConst() != nil
+		case pg_query.A_Expr_Kind_AEXPR_IN:
+			return pgOperatorIs(e.GetName(), "=") && lo.EveryBy(e.GetRexpr().GetList().GetItems(), func(item *pg_query.Node) bool {
+				return item.GetAConst() != nil
+			})
+		}
+	}
+	return false
+}
+
+// pgHasLimit returns whether the WHERE clause has a subquery with LIMIT, e.g. id IN (SELECT id FROM t ... LIMIT 1000),
+// which changes the rows in batches.
+func pgHasLimit(where *pg_query.Node) bool {
+	found := false
+	pgWalk(where, func(node any) bool {
+		if sel, ok := node.(*pg_query.SelectStmt); ok && sel.GetLimitCount() != nil {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,100 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPostgreSQL_DML(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs and objects of the findings, including the operational ones
+	}{
+		{name: "DeleteByKey", sql: "DELETE FROM users WHERE id = 1;"},
+		{name: "DeleteByKeys", sql: "DELETE FROM users WHERE id IN (1, 2, 3) AND deleted_at IS NOT NULL;"},
+		{name: "UpdateInBatch", sql: "UPDATE users SET status = 'inactive' WHERE id IN (SELECT id FROM users WHERE last_login_at < '2020-01-01' LIMIT 1000);"},
+		{name: "InsertValues", sql: "INSERT INTO users (id, name) VALUES (1, 'alice');"},
+		{name: "InsertSelectWithLimit", sql: "INSERT INTO archived_users SELECT * FROM users WHERE id > 1000 ORDER BY id LIMIT 1000;"},
+		{
+			name: "DeleteWithoutWhere",
+			sql:  "DELETE FROM public.users;",
+			want: []string{"pg/delete-all-rows public.users", "pg/unbatched-dml public.users"},
+		},
+		{
+			name: "UpdateWithoutWhere",
+			sql:  "UPDATE users SET status = 'inactive', score = 0;",
+			want: []string{"pg/update-all-rows users", "pg/unbatched-dml users"},
+		},
+		{
+			name: "AlwaysTrueWhere",
+			sql:  "DELETE FROM users WHERE 1 = 1;\nUPDATE users SET score = 0 WHERE true;\nDELETE FROM users WHERE 'a' = 'a' OR id = 1;",
+			want: []string{
+				"pg/delete-all-rows users", "pg/unbatched-dml users",
+				"pg/update-all-rows users", "pg/unbatched-dml users",
+				"pg/delete-all-rows users", "pg/unbatched-dml users",
+			},
+		},
+		{
+			name: "NotAlwaysTrueWhere",
+			sql:  "DELETE FROM users WHERE 1 = 0 OR id = 1;\nDELETE FROM users WHERE false;",
+			want: []string{"pg/unbatched-dml users", "pg/unbatched-dml users"},
+		},
+		{
+			name: "UnbatchedDelete",
+			sql:  "DELETE FROM users WHERE deleted_at IS NOT NULL;",
+			want: []string{"pg/unbatched-dml users"},
+		},
+		{
+			name: "DeleteUsing",
+			sql:  "DELETE FROM posts USING users WHERE posts.user_id = users.id;",
+			want: []string{"pg/unbatched-dml posts"},
+		},
+		{
+			name: "UpdateFrom",
+			sql:  "UPDATE users SET score = s.score FROM user_stats s WHERE s.user_id = users.id;",
+			want: []string{"pg/unbatched-dml users"},
+		},
+		{
+			name: "InsertSelect",
+			sql:  "INSERT INTO archived_users SELECT * FROM users WHERE deleted_at IS NOT NULL;",
+			want: []string{"pg/unbatched-dml archived_users"},
+		},
+		{
+			name: "InsertSelectOnConflictDoUpdate",
+			sql:  "INSERT INTO user_stats (user_id, posts) SELECT user_id, count(*) FROM posts GROUP BY user_id ON CONFLICT (user_id) DO UPDATE SET posts = excluded.posts;",
+			want: []string{"pg/unbatched-dml user_stats"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunPostgreSQLWithOptions(tt.sql, breaql.Options{OperationalRisks: true})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+				return f.RuleID + " " + f.Object
+			}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunPostgreSQLWithOptions() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunPostgreSQL_DMLMessages(t *testing.T) {
+	got, err := breaql.RunPostgreSQLWithOptions("INSERT INTO user_stats (user_id, posts) SELECT user_id, count(*) FROM posts GROUP BY user_id ON CONFLICT (user_id) DO UPDATE SET posts = excluded.posts;\nUPDATE users SET status = 'inactive' WHERE true;",
+		breaql.Options{OperationalRisks: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"rows of table user_stats are backfilled by a single INSERT ... SELECT ... ON CONFLICT DO UPDATE without LIMIT, which overwrites the existing rows, locks them until it commits and leaves as many dead tuples",
+		"UPDATE with an always-true WHERE clause overwrites status of the rows of table users regardless of their values",
+		"rows of table users are updated by a single statement without a batch limit, which locks the rows until it commits and leaves as many dead tuples",
+	}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.Message }))
+	assert.Equal(t, breaql.CategoryOperational, got.Findings[0].Category)
+	assert.Equal(t, breaql.CategoryBreaking, got.Findings[1].Category)
+}
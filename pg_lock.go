@@ -0,0 +1,343 @@
+package breaql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// pgLock is a table-level lock mode of PostgreSQL, in ascending order of strength.
+// Only the modes taken by DDL are listed.
+type pgLock int
+
+const (
+	pgLockNone                 pgLock = iota
+	pgLockShareUpdateExclusive        // blocks other DDL only
+	pgLockShare                       // blocks writes
+	pgLockShareRowExclusive           // blocks writes
+	pgLockAccessExclusive             // blocks reads and writes
+)
+
+func (l pgLock) String() string {
+	switch l {
+	case pgLockShareUpdateExclusive:
+		return "SHARE UPDATE EXCLUSIVE"
+	case pgLockShare:
+		return "SHARE"
+	case pgLockShareRowExclusive:
+		return "SHARE ROW EXCLUSIVE"
+	case pgLockAccessExclusive:
+		return "ACCESS EXCLUSIVE"
+	}
+	return ""
+}
+
+// blocks returns the queries the lock blocks.
+func (l pgLock) blocks() string {
+	if l == pgLockAccessExclusive {
+		return "reads and writes"
+	}
+	return "writes"
+}
+
+// pgWork is what a statement does to the table while holding the lock.
+type pgWork int
+
+const (
+	pgWorkCatalog pgWork = iota // only updates the catalog, which is quick once the lock is acquired
+	pgWorkScan                  // reads the whole table, e.g. to validate a constraint or build an index
+	pgWorkRewrite               // writes a new copy of the table and its indexes
+)
+
+// pgLockAction is the lock taken by a part of a statement and the work done under it.
+type pgLockAction struct {
+	lock   pgLock
+	work   pgWork
+	reason string // what needs the work, e.g. "column token is added with the volatile default gen_random_uuid()"
+}
+
+// pgLockFindings returns the operational risks of the given statement: a lock blocking the queries on a table
+// for as long as it rewrites or scans the table.
+func pgLockFindings(stmt statement, schema *Schema, node *pg_query.Node) []Finding {
+	switch n := node.GetNode().(type) {
+	case *pg_query.Node_AlterTableStmt:
+		rv := n.AlterTableStmt.GetRelation()
+		if rv == nil || n.AlterTableStmt.GetObjtype() != pg_query.ObjectType_OBJECT_TABLE {
+			return nil
+		}
+		table := pgRangeVarName(rv)
+		t := schema.table(table)
+		var actions []pgLockAction
+		for _, cmd := range n.AlterTableStmt.GetCmds() {
+			actions = append(actions, pgAlterTableLock(t, cmd.GetAlterTableCmd())...)
+		}
+		return pgLockActionFindings(stmt, ObjectKindTable, table, actions)
+
+	case *pg_query.Node_IndexStmt:
+		if n.IndexStmt.GetConcurrent() {
+			return nil
+		}
+		table := pgRangeVarName(n.IndexStmt.GetRelation())
+		f := stmt.finding("pg/lock-non-concurrent-index", ObjectKindTable, table,
+			fmt.Sprintf("index is built on table %s under a SHARE lock, which blocks writes until the build finishes; use CREATE INDEX CONCURRENTLY", table))
+		f.Lock = pgLockShare.String()
+		return []Finding{f}
+
+	case *pg_query.Node_DropStmt:
+		if n.DropStmt.GetRemoveType() != pg_query.ObjectType_OBJECT_INDEX || n.DropStmt.GetConcurrent() {
+			return nil
+		}
+		var findings []Finding
+		for _, obj := range n.DropStmt.GetObjects() {
+			index := pgObjectName(obj)
+			f := stmt.finding("pg/lock-non-concurrent-index", ObjectKindIndex, index,
+				fmt.Sprintf("index %s is dropped under an ACCESS EXCLUSIVE lock on its table, which blocks reads and writes until the running transactions end; use DROP INDEX CONCURRENTLY", index))
+			f.Lock = pgLockAccessExclusive.String()
+			findings = append(findings, f)
+		}
+		return findings
+
+	case *pg_query.Node_ReindexStmt:
+		concurrent := slices.ContainsFunc(n.ReindexStmt.GetParams(), func(param *pg_query.Node) bool {
+			return param.GetDefElem().GetDefname() == "concurrently"
+		})
+		if concurrent || n.ReindexStmt.GetRelation() == nil {
+			return nil
+		}
+		object := pgRangeVarName(n.ReindexStmt.GetRelation())
+		kind := ObjectKindTable
+		if n.ReindexStmt.GetKind() == pg_query.ReindexObjectType_REINDEX_OBJECT_INDEX {
+			kind = ObjectKindIndex
+		}
+		f := stmt.finding("pg/lock-non-concurrent-index", kind, object,
+			fmt.Sprintf("%s %s is reindexed under a SHARE lock on the table, which blocks writes until the rebuild finishes; use REINDEX CONCURRENTLY", kind, object))
+		f.Lock = pgLockShare.String()
+		return []Finding{f}
+
+	case *pg_query.Node_VacuumStmt:
+		full := slices.ContainsFunc(n.VacuumStmt.GetOptions(), func(opt *pg_query.Node) bool {
+			return opt.GetDefElem().GetDefname() == "full"
+		})
+		if !full || !n.VacuumStmt.GetIsVacuumcmd() {
+			return nil
+		}
+		var findings []Finding
+		for _, rel := range n.VacuumStmt.GetRels() {
+			table := pgRangeVarName(rel.GetVacuumRelation().GetRelation())
+			findings = append(findings, pgLockActionFindings(stmt, ObjectKindTable, table,
+				[]pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkRewrite, reason: "VACUUM FULL copies the table"}})...)
+		}
+		return findings
+
+	case *pg_query.Node_ClusterStmt:
+		if rv := n.ClusterStmt.GetRelation(); rv != nil {
+			return pgLockActionFindings(stmt, ObjectKindTable, pgRangeVarName(rv),
+				[]pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkRewrite, reason: "CLUSTER copies the table in the order of the index"}})
+		}
+
+	case *pg_query.Node_RefreshMatViewStmt:
+		if n.RefreshMatViewStmt.GetConcurrent() {
+			return nil
+		}
+		view := pgRangeVarName(n.RefreshMatViewStmt.GetRelation())
+		return pgLockActionFindings(stmt, ObjectKindView, view,
+			[]pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkRewrite, reason: "the query of the materialized view is run again"}})
+	}
+	return nil
+}
+
+// pgLockActionFindings returns the finding on the strongest of the locks taken on the object by a statement,
+// classified by the heaviest work done under it.
+func pgLockActionFindings(stmt statement, kind ObjectKind, object string, actions []pgLockAction) []Finding {
+	var lock pgLock
+	var work pgWork
+	var reasons []string
+	for _, action := range actions {
+		lock = max(lock, action.lock)
+		switch {
+		case action.work > work:
+			work, reasons = action.work, []string{action.reason}
+		case action.work == work && action.work > pgWorkCatalog:
+			reasons = append(reasons, action.reason)
+		}
+	}
+	// SHARE UPDATE EXCLUSIVE and weaker locks do not block queries.
+	if lock < pgLockShare {
+		return nil
+	}
+
+	var f Finding
+	switch work {
+	case pgWorkRewrite:
+		f = stmt.finding("pg/lock-table-rewrite", kind, object,
+			fmt.Sprintf("%s %s is rewritten under an %s lock, which blocks %s until it finishes: %s", kind, object, lock, lock.blocks(), strings.Join(reasons, "; ")))
+	case pgWorkScan:
+		f = stmt.finding("pg/lock-table-scan", kind, object,
+			fmt.Sprintf("%s %s is scanned under a%s %s lock, which blocks %s until it finishes: %s", kind, object, pgArticle(lock), lock, lock.blocks(), strings.Join(reasons, "; ")))
+	default:
+		// The lock is held only briefly for a catalog change, which is not reported.
+		return nil
+	}
+	f.Lock = lock.String()
+	return []Finding{f}
+}
+
+func pgArticle(lock pgLock) string {
+	if lock == pgLockAccessExclusive {
+		return "n"
+	}
+	return ""
+}
+
+// pgAlterTableLock returns the lock taken by the given ALTER TABLE command and the work done under it.
+// t is the table in the baseline schema, or nil if unknown.
+func pgAlterTableLock(t *schemaTable, c *pg_query.AlterTableCmd) []pgLockAction {
+	switch c.GetSubtype() {
+	case pg_query.AlterTableType_AT_AddColumn:
+		def := c.GetDef().GetColumnDef()
+		if pgSerial(def) {
+			return []pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkRewrite,
+				reason: fmt.Sprintf("serial column %s is filled from a sequence", def.GetColname())}}
+		}
+		actions := []pgLockAction{{lock: pgLockAccessExclusive}}
+		for _, cons := range def.GetConstraints() {
+			cons := cons.GetConstraint()
+			switch cons.GetContype() {
+			case pg_query.ConstrType_CONSTR_DEFAULT:
+				if fn := pgVolatileFunction(cons.GetRawExpr()); fn != "" {
+					actions = append(actions, pgLockAction{lock: pgLockAccessExclusive, work: pgWorkRewrite,
+						reason: fmt.Sprintf("column %s is added with the volatile default %s()", def.GetColname(), fn)})
+				}
+			case pg_query.ConstrType_CONSTR_IDENTITY:
+				actions = append(actions, pgLockAction{lock: pgLockAccessExclusive, work: pgWorkRewrite,
+					reason: fmt.Sprintf("identity column %s is filled from a sequence", def.GetColname())})
+			case pg_query.ConstrType_CONSTR_GENERATED:
+				actions = append(actions, pgLockAction{lock: pgLockAccessExclusive, work: pgWorkRewrite,
+					reason: fmt.Sprintf("generated column %s is computed for every row", def.GetColname())})
+			default:
+				if a, ok := pgAddConstraintLock(cons, def.GetColname()); ok {
+					actions = append(actions, a)
+				}
+			}
+		}
+		return actions
+
+	case pg_query.AlterTableType_AT_AlterColumnType:
+		def := c.GetDef().GetColumnDef()
+		if old := t.column(c.GetName()); old != nil && def.GetRawDefault() == nil {
+			if rewrite, _ := pgTypeChange(old.typ, pgColumnType(def.GetTypeName())); !rewrite {
+				return []pgLockAction{{lock: pgLockAccessExclusive}}
+			}
+		}
+		return []pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkRewrite,
+			reason: fmt.Sprintf("type of column %s is changed", c.GetName())}}
+
+	case pg_query.AlterTableType_AT_SetNotNull:
+		return []pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkScan,
+			reason: fmt.Sprintf("column %s is checked for NULL values", c.GetName())}}
+
+	case pg_query.AlterTableType_AT_AddConstraint:
+		if a, ok := pgAddConstraintLock(c.GetDef().GetConstraint(), ""); ok {
+			return []pgLockAction{a}
+		}
+		return []pgLockAction{{lock: pgLockAccessExclusive}}
+
+	case pg_query.AlterTableType_AT_SetTableSpace:
+		return []pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkRewrite,
+			reason: fmt.Sprintf("the table is moved to tablespace %s", c.GetName())}}
+
+	case pg_query.AlterTableType_AT_SetLogged, pg_query.AlterTableType_AT_SetUnLogged:
+		return []pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkRewrite, reason: "the persistence of the table is changed"}}
+
+	case pg_query.AlterTableType_AT_AttachPartition:
+		// The parent is locked in SHARE UPDATE EXCLUSIVE mode, and the attached partition in ACCESS EXCLUSIVE mode.
+		partition := pgRangeVarName(c.GetDef().GetPartitionCmd().GetName())
+		return []pgLockAction{{lock: pgLockAccessExclusive, work: pgWorkScan,
+			reason: fmt.Sprintf("partition %s is checked against the partition bound unless a constraint implies it", partition)}}
+
+	case pg_query.AlterTableType_AT_DetachPartition:
+		if c.GetDef().GetPartitionCmd().GetConcurrent() {
+			return []pgLockAction{{lock: pgLockShareUpdateExclusive}}
+		}
+		return []pgLockAction{{lock: pgLockAccessExclusive}}
+
+	case pg_query.AlterTableType_AT_ValidateConstraint, pg_query.AlterTableType_AT_SetStatistics,
+		pg_query.AlterTableType_AT_ClusterOn, pg_query.AlterTableType_AT_DropCluster:
+		return []pgLockAction{{lock: pgLockShareUpdateExclusive}}
+
+	case pg_query.AlterTableType_AT_EnableTrig, pg_query.AlterTableType_AT_DisableTrig,
+		pg_query.AlterTableType_AT_EnableAlwaysTrig, pg_query.AlterTableType_AT_EnableReplicaTrig,
+		pg_query.AlterTableType_AT_EnableTrigAll, pg_query.AlterTableType_AT_DisableTrigAll,
+		pg_query.AlterTableType_AT_EnableTrigUser, pg_query.AlterTableType_AT_DisableTrigUser:
+		return []pgLockAction{{lock: pgLockShareRowExclusive}}
+	}
+	// ALTER TABLE takes an ACCESS EXCLUSIVE lock unless noted otherwise.
+	return []pgLockAction{{lock: pgLockAccessExclusive}}
+}
+
+// pgAddConstraintLock returns the lock taken to add the given constraint and the work done under it.
+// column is the column defining the constraint, if any.
+func pgAddConstraintLock(cons *pg_query.Constraint, column string) (pgLockAction, bool) {
+	label := func(noun string, keys []*pg_query.Node) string {
+		columns := pgKeyNames(keys)
+		if len(columns) == 0 && column != "" {
+			columns = []string{column}
+		}
+		return constraintLabel(noun, cons.GetConname(), columns)
+	}
+	switch cons.GetContype() {
+	case pg_query.ConstrType_CONSTR_FOREIGN:
+		// Both the table and the referenced one are locked.
+		if cons.GetSkipValidation() {
+			return pgLockAction{lock: pgLockShareRowExclusive}, true
+		}
+		return pgLockAction{lock: pgLockShareRowExclusive, work: pgWorkScan,
+			reason: fmt.Sprintf("%s is validated against table %s", label("foreign key", cons.GetFkAttrs()), pgRangeVarName(cons.GetPktable()))}, true
+
+	case pg_query.ConstrType_CONSTR_CHECK:
+		if cons.GetSkipValidation() {
+			return pgLockAction{lock: pgLockAccessExclusive}, true
+		}
+		return pgLockAction{lock: pgLockAccessExclusive, work: pgWorkScan,
+			reason: fmt.Sprintf("%s is validated", label("check constraint", nil))}, true
+
+	case pg_query.ConstrType_CONSTR_PRIMARY, pg_query.ConstrType_CONSTR_UNIQUE, pg_query.ConstrType_CONSTR_EXCLUSION:
+		if cons.GetIndexname() != "" {
+			return pgLockAction{lock: pgLockAccessExclusive}, true
+		}
+		noun := map[pg_query.ConstrType]string{
+			pg_query.ConstrType_CONSTR_PRIMARY:   "primary key",
+			pg_query.ConstrType_CONSTR_UNIQUE:    "unique constraint",
+			pg_query.ConstrType_CONSTR_EXCLUSION: "exclusion constraint",
+		}[cons.GetContype()]
+		return pgLockAction{lock: pgLockAccessExclusive, work: pgWorkScan,
+			reason: fmt.Sprintf("the index of %s is built", label(noun, cons.GetKeys()))}, true
+	}
+	return pgLockAction{}, false
+}
+
+// pgStableFunctions are the functions commonly used in column defaults that are not volatile,
+// so that adding a column with them as the default does not rewrite the table.
+// Other functions are assumed volatile, which is the default of CREATE FUNCTION.
+var pgStableFunctions = []string{
+	"now", "statement_timestamp", "transaction_timestamp", "current_setting",
+	"lower", "upper", "concat", "to_char", "to_date", "to_timestamp", "to_json", "to_jsonb", "make_date", "make_interval",
+	"json_build_object", "jsonb_build_object", "json_build_array", "jsonb_build_array", "array_fill",
+}
+
+// pgVolatileFunction returns the name of the first volatile function called in the given expression, if any.
+func pgVolatileFunction(expr *pg_query.Node) string {
+	var volatile string
+	pgWalk(expr, func(node any) bool {
+		if fn, ok := node.(*pg_query.FuncCall); ok && volatile == "" {
+			name := strings.ToLower(unqualified(pgNames(fn.GetFuncname())))
+			if !slices.Contains(pgStableFunctions, name) {
+				volatile = name
+			}
+		}
+		return volatile == ""
+	})
+	return volatile
+}
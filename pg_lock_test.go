@@ -0,0 +1,152 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPostgreSQL_OperationalRisks(t *testing.T) {
+	baseline, err := breaql.LoadPostgreSQLSchema(pgBaseline)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs, objects and locks of the operational findings
+	}{
+		{name: "CreateTable", sql: "CREATE TABLE posts (id int PRIMARY KEY);"},
+		{name: "CreateIndexConcurrently", sql: "CREATE INDEX CONCURRENTLY users_name_idx ON users (name);"},
+		{name: "DropIndexConcurrently", sql: "DROP INDEX CONCURRENTLY users_name_idx;"},
+		{name: "ValidateConstraint", sql: "ALTER TABLE users VALIDATE CONSTRAINT users_score_check;"},
+		{name: "RefreshConcurrently", sql: "REFRESH MATERIALIZED VIEW CONCURRENTLY user_stats;"},
+		{name: "DropTable", sql: "DROP TABLE users;"},
+		{name: "Truncate", sql: "TRUNCATE users;"},
+		{name: "RenameTable", sql: "ALTER TABLE users RENAME TO members;"},
+		{name: "AddColumn", sql: "ALTER TABLE users ADD COLUMN age int DEFAULT 0, ADD COLUMN joined_at timestamptz DEFAULT now();"},
+		{
+			name: "AddColumnVolatileDefault",
+			sql:  "ALTER TABLE users ADD COLUMN token uuid DEFAULT gen_random_uuid();",
+			want: []string{"pg/lock-table-rewrite users ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "AddSerialColumn",
+			sql:  "ALTER TABLE users ADD COLUMN seq bigserial;",
+			want: []string{"pg/lock-table-rewrite users ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "AddIdentityColumn",
+			sql:  "ALTER TABLE users ADD COLUMN seq int GENERATED ALWAYS AS IDENTITY;",
+			want: []string{"pg/lock-table-rewrite users ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "SetNotNull",
+			sql:  "ALTER TABLE users ALTER COLUMN score SET NOT NULL;",
+			want: []string{"pg/lock-table-scan users ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "AddForeignKey",
+			sql:  "ALTER TABLE posts ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id);",
+			want: []string{"pg/lock-table-scan posts SHARE ROW EXCLUSIVE"},
+		},
+		{name: "AddForeignKeyNotValid", sql: "ALTER TABLE posts ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;"},
+		{name: "AddCheckNotValid", sql: "ALTER TABLE users ADD CONSTRAINT users_score_check CHECK (score >= 0) NOT VALID;"},
+		{
+			name: "AddCheck",
+			sql:  "ALTER TABLE users ADD CONSTRAINT users_score_check CHECK (score >= 0);",
+			want: []string{"pg/lock-table-scan users ACCESS EXCLUSIVE"},
+		},
+		{name: "AddUniqueUsingIndex", sql: "ALTER TABLE users ADD CONSTRAINT users_name_key UNIQUE USING INDEX users_name_idx;"},
+		{
+			name: "AddUnique",
+			sql:  "ALTER TABLE users ADD CONSTRAINT users_name_key UNIQUE (name);",
+			want: []string{"pg/lock-table-scan users ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "RewriteOutweighsScan",
+			sql:  "ALTER TABLE users ALTER COLUMN score SET NOT NULL, ALTER COLUMN score TYPE bigint;",
+			want: []string{"pg/lock-table-rewrite users ACCESS EXCLUSIVE"},
+		},
+		{name: "CoercibleTypeChange", sql: "ALTER TABLE users ALTER COLUMN name TYPE text;"},
+		{name: "WidenNumericPrecision", sql: "ALTER TABLE users ALTER COLUMN balance TYPE numeric(12,2);"},
+		{
+			name: "NarrowNumericPrecision",
+			sql:  "ALTER TABLE users ALTER COLUMN balance TYPE numeric(8,2);",
+			want: []string{"pg/lock-table-rewrite users ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "UnknownTypeChange",
+			sql:  "ALTER TABLE posts ALTER COLUMN title TYPE text;",
+			want: []string{"pg/lock-table-rewrite posts ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "CreateIndex",
+			sql:  "CREATE INDEX users_name_idx ON users (name);",
+			want: []string{"pg/lock-non-concurrent-index users SHARE"},
+		},
+		{
+			name: "DropIndex",
+			sql:  "DROP INDEX users_name_idx;",
+			want: []string{"pg/lock-non-concurrent-index users_name_idx ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "Reindex",
+			sql:  "REINDEX TABLE users;",
+			want: []string{"pg/lock-non-concurrent-index users SHARE"},
+		},
+		{
+			name: "VacuumFull",
+			sql:  "VACUUM (FULL, ANALYZE) users;",
+			want: []string{"pg/lock-table-rewrite users ACCESS EXCLUSIVE"},
+		},
+		{
+			name: "RefreshMaterializedView",
+			sql:  "REFRESH MATERIALIZED VIEW user_stats;",
+			want: []string{"pg/lock-table-rewrite user_stats ACCESS EXCLUSIVE"},
+		},
+		{name: "EnableTrigger", sql: "ALTER TABLE users ENABLE TRIGGER users_audit;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunPostgreSQLWithOptions(tt.sql, breaql.Options{Baseline: baseline, OperationalRisks: true})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.OperationalRisks(), func(f breaql.Finding, _ int) string {
+				return f.RuleID + " " + f.Object + " " + f.Lock
+			}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunPostgreSQLWithOptions() operational risks mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunPostgreSQL_OperationalRisksSeparated(t *testing.T) {
+	sql := "CREATE INDEX users_name_idx ON users (name);\nALTER TABLE users DROP COLUMN bio;"
+
+	got, err := breaql.RunPostgreSQL(sql)
+	require.NoError(t, err)
+	assert.Empty(t, got.OperationalRisks(), "operational risks are reported only if enabled")
+
+	got, err = breaql.RunPostgreSQLWithOptions(sql, breaql.Options{OperationalRisks: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pg/lock-non-concurrent-index", "pg/drop-column"},
+		lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID }))
+	assert.Equal(t, breaql.TableChanges{"users": {"ALTER TABLE users DROP COLUMN bio;"}}, got.Tables)
+	assert.Equal(t, breaql.CategoryOperational, got.Findings[0].Category)
+	assert.Equal(t, breaql.CategoryBreaking, got.Findings[1].Category)
+}
+
+func TestRunPostgreSQL_OperationalRiskMessages(t *testing.T) {
+	got, err := breaql.RunPostgreSQLWithOptions("ALTER TABLE users ADD PRIMARY KEY (id);\nALTER TABLE posts ADD FOREIGN KEY (user_id) REFERENCES users (id);",
+		breaql.Options{OperationalRisks: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"table users is scanned under an ACCESS EXCLUSIVE lock, which blocks reads and writes until it finishes: the index of primary key on (id) is built",
+		"table posts is scanned under a SHARE ROW EXCLUSIVE lock, which blocks writes until it finishes: foreign key on (user_id) is validated against table users",
+	}, lo.Map(got.OperationalRisks(), func(f breaql.Finding, _ int) string { return f.Message }))
+}
@@ -0,0 +1,556 @@
+package breaql
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// psqlMetaCommand matches the lines of psql meta-commands such as `\restrict` emitted by recent pg_dump versions.
+var psqlMetaCommand = regexp.MustCompile(`(?m)^\\.*$`)
+
+// LoadPostgreSQLSchema builds a catalog from the CREATE TABLE statements in sql, e.g. the output of `pg_dump --schema-only`.
+// Other statements altering the tables are applied as well, and the rest are ignored.
+func LoadPostgreSQLSchema(sql string) (*Schema, error) {
+	// Blank out the meta-commands, keeping the offsets of the statements.
+	sql = psqlMetaCommand.ReplaceAllStringFunc(sql, func(line string) string { return strings.Repeat(" ", len(line)) })
+	tree, stmts, err := parsePostgreSQL(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{driver: "pg"}
+	for i, rawStmt := range tree.GetStmts() {
+		applyPostgreSQLStmt(schema, stmts[i], rawStmt.GetStmt())
+	}
+	return schema, nil
+}
+
+// applyPostgreSQLStmt updates the catalog with the given statement.
+func applyPostgreSQLStmt(schema *Schema, stmt statement, node *pg_query.Node) {
+	switch n := node.GetNode().(type) {
+	case *pg_query.Node_CreateStmt:
+		t := &schemaTable{name: pgRangeVarName(n.CreateStmt.GetRelation()), stmt: stmt}
+		for _, elt := range n.CreateStmt.GetTableElts() {
+			if def := elt.GetColumnDef(); def != nil {
+				t.columns = append(t.columns, pgSchemaColumn(def))
+			}
+		}
+		for _, elt := range n.CreateStmt.GetTableElts() {
+			if def := elt.GetColumnDef(); def != nil {
+				pgApplyColumnConstraints(t, def)
+			}
+			if cons := elt.GetConstraint(); cons != nil {
+				pgApplyConstraint(t, cons, nil)
+			}
+		}
+		schema.putTable(t)
+
+	case *pg_query.Node_IndexStmt:
+		if t := schema.table(pgRangeVarName(n.IndexStmt.GetRelation())); t != nil {
+			idx := &schemaIndex{name: n.IndexStmt.GetIdxname(), unique: n.IndexStmt.GetUnique()}
+			for _, param := range n.IndexStmt.GetIndexParams() {
+				if name := param.GetIndexElem().GetName(); name != "" {
+					idx.columns = append(idx.columns, name)
+				} else {
+					idx.columns = append(idx.columns, "(expression)")
+				}
+			}
+			if idx.name == "" {
+				idx.name = unqualified(t.name) + "_" + strings.Join(idx.columns, "_") + "_idx"
+			}
+			t.putIndex(idx)
+		}
+
+	case *pg_query.Node_ViewStmt:
+		schema.putView(&schemaView{
+			name:      pgRangeVarName(n.ViewStmt.GetView()),
+			relations: pgRelations(n.ViewStmt.GetQuery()),
+		})
+
+	case *pg_query.Node_CreateTableAsStmt:
+		if n.CreateTableAsStmt.GetObjtype() == pg_query.ObjectType_OBJECT_MATVIEW {
+			schema.putView(&schemaView{
+				name:      pgRangeVarName(n.CreateTableAsStmt.GetInto().GetRel()),
+				relations: pgRelations(n.CreateTableAsStmt.GetQuery()),
+			})
+		}
+
+	case *pg_query.Node_DropStmt:
+		for _, c := range pgCascadedObjects(schema, n.DropStmt) {
+			switch {
+			case c.column != "":
+				schema.table(c.object).dropColumn(c.column)
+			case c.constraint != "":
+				schema.table(c.object).dropConstraint(c.constraint)
+			case c.kind == ObjectKindView:
+				schema.dropView(c.object)
+			default:
+				schema.dropTable(c.object)
+			}
+		}
+		switch n.DropStmt.GetRemoveType() {
+		case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_FOREIGN_TABLE:
+			for _, obj := range n.DropStmt.GetObjects() {
+				schema.dropTable(pgQualifiedName(obj))
+			}
+		case pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+			for _, obj := range n.DropStmt.GetObjects() {
+				schema.dropView(pgQualifiedName(obj))
+			}
+		case pg_query.ObjectType_OBJECT_INDEX:
+			for _, obj := range n.DropStmt.GetObjects() {
+				index := unqualified(pgQualifiedName(obj))
+				if t := schema.indexTable(index); t != nil {
+					t.dropIndex(index)
+				}
+			}
+		}
+
+	case *pg_query.Node_RenameStmt:
+		rv := n.RenameStmt.GetRelation()
+		if rv == nil {
+			switch n.RenameStmt.GetRenameType() {
+			case pg_query.ObjectType_OBJECT_TYPE, pg_query.ObjectType_OBJECT_DOMAIN:
+				schema.renameType(unqualified(pgObjectName(n.RenameStmt.GetObject())), n.RenameStmt.GetNewname())
+			}
+			return
+		}
+		if n.RenameStmt.GetRenameType() == pg_query.ObjectType_OBJECT_INDEX {
+			if t := schema.indexTable(rv.GetRelname()); t != nil {
+				t.index(rv.GetRelname()).name = n.RenameStmt.GetNewname()
+			}
+			return
+		}
+		switch n.RenameStmt.GetRenameType() {
+		case pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+			if v := schema.view(pgRangeVarName(rv)); v != nil {
+				old := v.name
+				v.name = strings.TrimSuffix(v.name, unqualified(v.name)) + n.RenameStmt.GetNewname()
+				schema.renameRelation(old, v.name)
+			}
+			return
+		}
+		t := schema.table(pgRangeVarName(rv))
+		if t == nil {
+			return
+		}
+		switch n.RenameStmt.GetRenameType() {
+		case pg_query.ObjectType_OBJECT_TABLE:
+			// The table stays in the same schema.
+			old := t.name
+			t.name = strings.TrimSuffix(t.name, unqualified(t.name)) + n.RenameStmt.GetNewname()
+			schema.renameRelation(old, t.name)
+		case pg_query.ObjectType_OBJECT_COLUMN:
+			if c := t.column(n.RenameStmt.GetSubname()); c != nil {
+				c.name = n.RenameStmt.GetNewname()
+			}
+		case pg_query.ObjectType_OBJECT_TABCONSTRAINT:
+			if c := t.constraint(n.RenameStmt.GetSubname()); c != nil {
+				c.name = n.RenameStmt.GetNewname()
+			}
+		}
+
+	case *pg_query.Node_AlterTableStmt:
+		rv := n.AlterTableStmt.GetRelation()
+		if rv == nil {
+			return
+		}
+		t := schema.table(pgRangeVarName(rv))
+		if t == nil {
+			return
+		}
+		for _, cmd := range n.AlterTableStmt.GetCmds() {
+			c := cmd.GetAlterTableCmd()
+			switch c.GetSubtype() {
+			case pg_query.AlterTableType_AT_AddColumn:
+				if def := c.GetDef().GetColumnDef(); def != nil {
+					t.putColumn(def.GetColname(), pgSchemaColumn(def))
+					pgApplyColumnConstraints(t, def)
+				}
+			case pg_query.AlterTableType_AT_DropColumn:
+				t.dropColumn(c.GetName())
+			case pg_query.AlterTableType_AT_AlterColumnType:
+				if col := t.column(c.GetName()); col != nil {
+					col.typ = pgColumnType(c.GetDef().GetColumnDef().GetTypeName())
+				}
+			case pg_query.AlterTableType_AT_SetNotNull, pg_query.AlterTableType_AT_DropNotNull:
+				if col := t.column(c.GetName()); col != nil {
+					col.notNull = c.GetSubtype() == pg_query.AlterTableType_AT_SetNotNull
+				}
+			case pg_query.AlterTableType_AT_AddConstraint:
+				pgApplyConstraint(t, c.GetDef().GetConstraint(), nil)
+			case pg_query.AlterTableType_AT_DropConstraint:
+				t.dropConstraint(c.GetName())
+			}
+		}
+	}
+}
+
+// pgCascaded is an object dropped along with the ones named by DROP ... CASCADE.
+type pgCascaded struct {
+	kind       ObjectKind
+	object     string // qualified as in the catalog
+	column     string // set for a column of a dropped type
+	constraint string // set for a foreign key referencing a dropped table
+	message    string
+}
+
+// pgCascadedObjects returns the objects in the catalog that the given DROP ... CASCADE statement drops
+// in addition to the named ones: the tables and views in dropped schemas, the columns of dropped types,
+// the views reading from dropped tables and views, and the foreign keys referencing dropped tables.
+// Objects the catalog does not track, such as functions and triggers, are not returned.
+func pgCascadedObjects(schema *Schema, drop *pg_query.DropStmt) []pgCascaded {
+	if drop.GetBehavior() != pg_query.DropBehavior_DROP_CASCADE {
+		return nil
+	}
+
+	var cascaded []pgCascaded
+	var relations []string // dropped tables and views
+	for _, obj := range drop.GetObjects() {
+		name := pgObjectName(obj)
+		switch drop.GetRemoveType() {
+		case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_FOREIGN_TABLE:
+			if t := schema.table(name); t != nil {
+				name = t.name
+			}
+			relations = append(relations, name)
+
+		case pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+			if v := schema.view(name); v != nil {
+				name = v.name
+			}
+			relations = append(relations, name)
+
+		case pg_query.ObjectType_OBJECT_SCHEMA:
+			for _, t := range schema.tables {
+				if strings.HasPrefix(t.name, name+".") {
+					cascaded = append(cascaded, pgCascaded{kind: ObjectKindTable, object: t.name,
+						message: fmt.Sprintf("table %s is dropped along with schema %s", t.name, name)})
+					relations = append(relations, t.name)
+				}
+			}
+			for _, v := range schema.views {
+				if strings.HasPrefix(v.name, name+".") {
+					cascaded = append(cascaded, pgCascaded{kind: ObjectKindView, object: v.name,
+						message: fmt.Sprintf("view %s is dropped along with schema %s", v.name, name)})
+					relations = append(relations, v.name)
+				}
+			}
+
+		case pg_query.ObjectType_OBJECT_TYPE, pg_query.ObjectType_OBJECT_DOMAIN:
+			// Column types are recorded without the schema.
+			typ := unqualified(name)
+			for _, t := range schema.tables {
+				for _, c := range t.columns {
+					if strings.TrimSuffix(c.typ.name, "[]") == typ {
+						cascaded = append(cascaded, pgCascaded{kind: ObjectKindTable, object: t.name, column: c.name,
+							message: fmt.Sprintf("column %s of table %s is dropped along with type %s", c.name, t.name, name)})
+					}
+				}
+			}
+		}
+	}
+
+	dropped := func(name string) string {
+		if i := slices.IndexFunc(relations, func(r string) bool { return sameRelation(r, name) }); i >= 0 {
+			return relations[i]
+		}
+		return ""
+	}
+
+	// Views reading from the dropped relations are dropped, and so are the views reading from them in turn.
+	for found := true; found; {
+		found = false
+		for _, v := range schema.views {
+			if dropped(v.name) != "" {
+				continue
+			}
+			for _, r := range v.relations {
+				if from := dropped(r); from != "" {
+					cascaded = append(cascaded, pgCascaded{kind: ObjectKindView, object: v.name,
+						message: fmt.Sprintf("view %s is dropped along with %s it reads from", v.name, from)})
+					relations = append(relations, v.name)
+					found = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, t := range schema.tables {
+		if dropped(t.name) != "" {
+			continue
+		}
+		for _, c := range t.constraints {
+			if c.kind != constraintForeignKey || c.references == "" {
+				continue
+			}
+			if to := dropped(c.references); to != "" {
+				cascaded = append(cascaded, pgCascaded{kind: ObjectKindTable, object: t.name, constraint: c.name,
+					message: fmt.Sprintf("foreign key %s of table %s is dropped along with %s it references", c.name, t.name, to)})
+			}
+		}
+	}
+	return cascaded
+}
+
+// pgRelations returns the names of the tables and views referenced in the given query.
+// Names defined by WITH clauses are included as well.
+func pgRelations(query *pg_query.Node) []string {
+	var names []string
+	pgWalk(query, func(node any) bool {
+		if rv, ok := node.(*pg_query.RangeVar); ok {
+			names = append(names, pgRangeVarName(rv))
+			return false
+		}
+		return true
+	})
+	return slices.Compact(names)
+}
+
+// pgWalk calls visit for every message under the given node in depth-first order.
+// The messages under one are skipped if visit returns false.
+func pgWalk(node any, visit func(node any) bool) {
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if v.IsNil() {
+				return
+			}
+			if v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct && !visit(v.Interface()) {
+				return
+			}
+			walk(v.Elem())
+		case reflect.Struct:
+			for i := range v.NumField() {
+				if v.Type().Field(i).IsExported() {
+					walk(v.Field(i))
+				}
+			}
+		case reflect.Slice:
+			for i := range v.Len() {
+				walk(v.Index(i))
+			}
+		}
+	}
+	walk(reflect.ValueOf(node))
+}
+
+// pgApplyColumnConstraints adds the constraints defined along with the column.
+func pgApplyColumnConstraints(t *schemaTable, def *pg_query.ColumnDef) {
+	for _, cons := range def.GetConstraints() {
+		pgApplyConstraint(t, cons.GetConstraint(), []string{def.GetColname()})
+	}
+}
+
+// pgApplyConstraint adds the constraint to the table.
+// columns are the ones of a column constraint, and nil for a table constraint.
+// Unnamed constraints are named after the rules of PostgreSQL.
+func pgApplyConstraint(t *schemaTable, cons *pg_query.Constraint, columns []string) {
+	var kind constraintKind
+	var suffix string
+	keys := cons.GetKeys()
+	switch cons.GetContype() {
+	case pg_query.ConstrType_CONSTR_PRIMARY:
+		kind, suffix = constraintPrimaryKey, "pkey"
+	case pg_query.ConstrType_CONSTR_UNIQUE:
+		kind, suffix = constraintUnique, "key"
+	case pg_query.ConstrType_CONSTR_FOREIGN:
+		kind, suffix = constraintForeignKey, "fkey"
+		keys = cons.GetFkAttrs()
+	case pg_query.ConstrType_CONSTR_CHECK:
+		kind, suffix = constraintCheck, "check"
+	case pg_query.ConstrType_CONSTR_EXCLUSION:
+		kind, suffix = constraintExclusion, "excl"
+	default:
+		return
+	}
+	if columns == nil {
+		for _, key := range keys {
+			columns = append(columns, key.GetString_().GetSval())
+		}
+	}
+
+	name := cons.GetConname()
+	if name == "" {
+		parts := append([]string{unqualified(t.name)}, columns...)
+		if kind == constraintPrimaryKey {
+			parts = parts[:1]
+		}
+		name = strings.Join(append(parts, suffix), "_")
+	}
+	c := &schemaConstraint{name: name, kind: kind, columns: columns}
+	if kind == constraintForeignKey {
+		c.references = pgRangeVarName(cons.GetPktable())
+	}
+	t.putConstraint(c)
+
+	// Columns in a primary key are implicitly NOT NULL.
+	if kind == constraintPrimaryKey {
+		for _, column := range columns {
+			if c := t.column(column); c != nil {
+				c.notNull = true
+			}
+		}
+	}
+}
+
+func pgSchemaColumn(def *pg_query.ColumnDef) *schemaColumn {
+	c := &schemaColumn{name: def.GetColname(), typ: pgColumnType(def.GetTypeName())}
+	for _, cons := range def.GetConstraints() {
+		switch cons.GetConstraint().GetContype() {
+		case pg_query.ConstrType_CONSTR_NOTNULL, pg_query.ConstrType_CONSTR_PRIMARY:
+			c.notNull = true
+		}
+	}
+	return c
+}
+
+// pgColumnType normalizes the given type name, e.g. `character varying(50)` into varchar with length 50.
+func pgColumnType(tn *pg_query.TypeName) columnType {
+	t := columnType{length: -1, scale: -1}
+	if names := tn.GetNames(); len(names) > 0 {
+		t.name = strings.ToLower(names[len(names)-1].GetString_().GetSval())
+	}
+	switch t.name {
+	case "smallserial":
+		t.name = "int2"
+	case "serial":
+		t.name = "int4"
+	case "bigserial":
+		t.name = "int8"
+	}
+
+	var mods []int
+	for _, mod := range tn.GetTypmods() {
+		mods = append(mods, int(mod.GetAConst().GetIval().GetIval()))
+	}
+	if pgIsTemporal(t.name) {
+		// The modifier of temporal types is the fractional seconds precision.
+		if len(mods) > 0 {
+			t.scale = mods[len(mods)-1]
+		}
+	} else {
+		if len(mods) > 0 {
+			t.length = mods[0]
+		}
+		if len(mods) > 1 {
+			t.scale = mods[1]
+		}
+	}
+
+	if len(tn.GetArrayBounds()) > 0 {
+		t.name += "[]"
+	}
+	return t
+}
+
+// pgColumnFindings compares the old type of a column with the new one given by ALTER COLUMN TYPE
+// and returns the findings on the change, if any. using tells whether the statement has a USING clause.
+func pgColumnFindings(stmt statement, table string, old *schemaColumn, new columnType, using bool) []Finding {
+	rewrite, lossless := pgTypeChange(old.typ, new)
+	var f Finding
+	switch {
+	case !lossless:
+		effect := "may fail or lose data"
+		if rewrite || using {
+			effect += " and rewrites the table under an ACCESS EXCLUSIVE lock"
+		}
+		f = stmt.finding("pg/narrow-column-type", ObjectKindTable, table,
+			fmt.Sprintf("type of column %s of table %s is changed from %s to %s, which %s", old.name, table, old.typ, new, effect))
+	case rewrite || using:
+		f = stmt.finding("pg/alter-column-type-rewrite", ObjectKindTable, table,
+			fmt.Sprintf("type of column %s of table %s is changed from %s to %s, which rewrites the table under an ACCESS EXCLUSIVE lock",
+				old.name, table, old.typ, new))
+	default:
+		return nil
+	}
+	f.Column = old.name
+	return []Finding{f}
+}
+
+// pgTypeChange returns whether changing the type of a column from old to new rewrites the table,
+// and whether every value of the old type is kept in the new type.
+func pgTypeChange(old, new columnType) (rewrite bool, lossless bool) {
+	// Arrays are converted element by element.
+	oldElem, oldArray := strings.CutSuffix(old.name, "[]")
+	newElem, newArray := strings.CutSuffix(new.name, "[]")
+	if oldArray && newArray {
+		old.name, new.name = oldElem, newElem
+	}
+
+	switch {
+	case old.name == new.name && old.length == new.length && old.scale == new.scale:
+		return false, true
+
+	// Binary-coercible changes only update the catalog.
+	case (old.name == "varchar" || old.name == "text") && new.name == "text",
+		(old.name == "varchar" || old.name == "text") && new.name == "varchar" && new.length < 0,
+		old.name == "varchar" && new.name == "varchar" && old.length >= 0 && new.length >= old.length,
+		old.name == "varbit" && new.name == "varbit" && (new.length < 0 || old.length >= 0 && new.length >= old.length),
+		old.name == "cidr" && new.name == "inet":
+		return false, true
+
+	case old.name == "numeric" && new.name == "numeric":
+		switch {
+		case new.length < 0:
+			return false, true
+		case old.length < 0:
+			return true, false
+		case new.scale == old.scale:
+			// A higher precision only updates the catalog, while a lower one checks every value by rewriting the table.
+			return new.length < old.length, new.length >= old.length
+		}
+		return true, new.length-max(new.scale, 0) >= old.length-max(old.scale, 0) && new.scale >= old.scale
+
+	case pgIsTemporal(old.name) && old.name == new.name:
+		// A higher precision keeps the values, while a lower one rounds them.
+		if new.scale < 0 || old.scale >= 0 && new.scale >= old.scale {
+			return false, true
+		}
+		return true, false
+
+	// The following changes keep the values but rewrite the table.
+	case pgIntegerRank(old.name) > 0 && pgIntegerRank(new.name) >= pgIntegerRank(old.name):
+		return true, true
+
+	case pgIntegerRank(old.name) > 0 && new.name == "numeric":
+		return true, new.length < 0 || new.length-max(new.scale, 0) >= pgIntegerDigits[old.name]
+
+	case old.name == "float4" && new.name == "float8":
+		return true, true
+
+	case old.name == "bpchar" && new.name == "bpchar":
+		return true, old.length >= 0 && new.length >= old.length
+
+	case old.name == "date" && (new.name == "timestamp" || new.name == "timestamptz"):
+		return true, true
+
+	default:
+		return true, false
+	}
+}
+
+var pgIntegerDigits = map[string]int{"int2": 5, "int4": 10, "int8": 19}
+
+func pgIntegerRank(name string) int {
+	switch name {
+	case "int2":
+		return 2
+	case "int4":
+		return 4
+	case "int8":
+		return 8
+	}
+	return 0
+}
+
+func pgIsTemporal(name string) bool {
+	return slices.Contains([]string{"timestamp", "timestamptz", "time", "timetz", "interval"}, name)
+}
@@ -0,0 +1,340 @@
+package breaql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pgBaseline = `
+\restrict abc123
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET default_tablespace = '';
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name character varying(50) NOT NULL,
+    bio text,
+    score integer,
+    balance numeric(10,2),
+    ratio real,
+    code character(8),
+    created_at timestamp(3) without time zone,
+    tags character varying(20)[]
+);
+
+ALTER TABLE public.users OWNER TO app;
+
+CREATE SEQUENCE public.users_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+\unrestrict abc123
+`
+
+func TestRunPostgreSQL_Baseline(t *testing.T) {
+	baseline, err := breaql.LoadPostgreSQLSchema(pgBaseline)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs and columns of the findings
+	}{
+		{name: "WidenVarchar", sql: "ALTER TABLE users ALTER COLUMN name TYPE varchar(100);"},
+		{name: "VarcharToText", sql: "ALTER TABLE users ALTER COLUMN name TYPE text;"},
+		{name: "TextToUnlimitedVarchar", sql: "ALTER TABLE users ALTER COLUMN bio TYPE varchar;"},
+		{name: "WidenNumericPrecision", sql: "ALTER TABLE users ALTER COLUMN balance TYPE numeric(12,2);"},
+		{name: "UnconstrainedNumeric", sql: "ALTER TABLE users ALTER COLUMN balance TYPE numeric;"},
+		{name: "WidenTimestampPrecision", sql: "ALTER TABLE public.users ALTER COLUMN created_at TYPE timestamp(6);"},
+		{name: "WidenArrayElements", sql: "ALTER TABLE users ALTER COLUMN tags TYPE text[];"},
+		{name: "SameType", sql: "ALTER TABLE users ALTER COLUMN score TYPE int4;"},
+		{
+			name: "IntToBigint",
+			sql:  "ALTER TABLE users ALTER COLUMN score TYPE bigint;",
+			want: []string{"pg/alter-column-type-rewrite score"},
+		},
+		{
+			name: "RealToDouble",
+			sql:  "ALTER TABLE users ALTER COLUMN ratio TYPE double precision;",
+			want: []string{"pg/alter-column-type-rewrite ratio"},
+		},
+		{
+			name: "WidenNumericScale",
+			sql:  "ALTER TABLE users ALTER COLUMN balance TYPE numeric(12,4);",
+			want: []string{"pg/alter-column-type-rewrite balance"},
+		},
+		{
+			name: "CoercibleWithUsing",
+			sql:  "ALTER TABLE users ALTER COLUMN name TYPE text USING lower(name);",
+			want: []string{"pg/alter-column-type-rewrite name"},
+		},
+		{
+			name: "NarrowVarchar",
+			sql:  "ALTER TABLE users ALTER COLUMN name TYPE varchar(20);",
+			want: []string{"pg/narrow-column-type name"},
+		},
+		{
+			name: "TextToLimitedVarchar",
+			sql:  "ALTER TABLE users ALTER COLUMN bio TYPE varchar(255);",
+			want: []string{"pg/narrow-column-type bio"},
+		},
+		{
+			name: "BigintToInt",
+			sql: `ALTER TABLE users ALTER COLUMN score TYPE bigint;
+				ALTER TABLE users ALTER COLUMN score TYPE integer;`,
+			want: []string{"pg/alter-column-type-rewrite score", "pg/narrow-column-type score"},
+		},
+		{
+			name: "NarrowNumeric",
+			sql:  "ALTER TABLE users ALTER COLUMN balance TYPE numeric(8,2);",
+			want: []string{"pg/narrow-column-type balance"},
+		},
+		{
+			name: "TextToInteger",
+			sql:  "ALTER TABLE users ALTER COLUMN bio TYPE integer USING bio::integer;",
+			want: []string{"pg/narrow-column-type bio"},
+		},
+		{
+			name: "ArrayToScalar",
+			sql:  "ALTER TABLE users ALTER COLUMN tags TYPE varchar(20);",
+			want: []string{"pg/narrow-column-type tags"},
+		},
+		{
+			name: "RenamedColumn",
+			sql: `ALTER TABLE users RENAME COLUMN bio TO profile;
+				ALTER TABLE users ALTER COLUMN profile TYPE varchar(10);`,
+			want: []string{"pg/rename-column bio", "pg/narrow-column-type profile"},
+		},
+		{
+			name: "UnknownColumn",
+			sql:  "ALTER TABLE users ALTER COLUMN missing TYPE text;",
+			want: []string{"pg/alter-column-type missing"},
+		},
+		{name: "AddNullableColumn", sql: "ALTER TABLE users ADD COLUMN age integer;"},
+		{name: "AddNotNullColumnWithDefault", sql: "ALTER TABLE users ADD COLUMN age integer NOT NULL DEFAULT 0;"},
+		{name: "AddSerialColumn", sql: "ALTER TABLE users ADD COLUMN seq bigserial NOT NULL;"},
+		{name: "AddIdentityColumn", sql: "ALTER TABLE users ADD COLUMN seq bigint NOT NULL GENERATED ALWAYS AS IDENTITY;"},
+		{
+			name: "AddNotNullColumn",
+			sql:  "ALTER TABLE users ADD COLUMN age integer NOT NULL, ADD COLUMN uuid uuid PRIMARY KEY;",
+			want: []string{"pg/add-not-null-column age", "pg/add-not-null-column uuid"},
+		},
+		{
+			name: "SetNotNull",
+			sql:  "ALTER TABLE users ALTER COLUMN score SET NOT NULL;",
+			want: []string{"pg/column-set-not-null score"},
+		},
+		{name: "SetNotNullAgain", sql: "ALTER TABLE users ALTER COLUMN name SET NOT NULL;"},
+		{
+			name: "SetNotNullOfUnknownColumn",
+			sql:  "ALTER TABLE users ALTER COLUMN missing SET NOT NULL;",
+			want: []string{"pg/column-set-not-null missing"},
+		},
+		{name: "SetDefault", sql: "ALTER TABLE users ALTER COLUMN score SET DEFAULT 0;"},
+		{name: "DropDefaultOfNullable", sql: "ALTER TABLE users ALTER COLUMN score DROP DEFAULT;"},
+		{
+			name: "DropDefaultOfNotNull",
+			sql:  "ALTER TABLE users ALTER COLUMN id DROP DEFAULT;",
+			want: []string{"pg/drop-column-default id"},
+		},
+		{
+			name: "DropDefaultOfUnknownColumn",
+			sql:  "ALTER TABLE users ALTER COLUMN missing DROP DEFAULT;",
+			want: []string{"pg/drop-column-default missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunPostgreSQLWithOptions(tt.sql, breaql.Options{Baseline: baseline})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID + " " + f.Column }), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunPostgreSQLWithOptions() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunPostgreSQL_NarrowColumnTypeMessages(t *testing.T) {
+	baseline, err := breaql.LoadPostgreSQLSchema(pgBaseline)
+	require.NoError(t, err)
+
+	got, err := breaql.RunPostgreSQLWithOptions("ALTER TABLE users ALTER COLUMN balance TYPE numeric(8,2);\nALTER TABLE users ALTER COLUMN balance TYPE numeric(6,1);",
+		breaql.Options{Baseline: baseline})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"type of column balance of table users is changed from NUMERIC(10,2) to NUMERIC(8,2), which may fail or lose data and rewrites the table under an ACCESS EXCLUSIVE lock",
+		"type of column balance of table users is changed from NUMERIC(8,2) to NUMERIC(6,1), which may fail or lose data and rewrites the table under an ACCESS EXCLUSIVE lock",
+	}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.Message }))
+}
+
+func TestRunPostgreSQL_BaselineCascade(t *testing.T) {
+	baseline, err := breaql.LoadPostgreSQLSchema(`
+CREATE TYPE public.mood AS ENUM ('happy', 'sad');
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    mood public.mood,
+    moods public.mood[]
+);
+
+CREATE TABLE public.posts (
+    id integer NOT NULL,
+    user_id integer
+);
+
+CREATE TABLE audit.logs (
+    id integer NOT NULL,
+    user_id integer
+);
+
+CREATE VIEW public.active_users AS
+ SELECT users.id
+   FROM public.users
+  WHERE (users.mood = 'happy'::public.mood);
+
+CREATE MATERIALIZED VIEW public.active_user_posts AS
+ SELECT p.id
+   FROM (public.posts p
+     JOIN public.active_users u ON ((u.id = p.user_id)));
+
+CREATE VIEW public.recent_logs AS
+ SELECT logs.id
+   FROM audit.logs;
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.posts
+    ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);
+
+ALTER TABLE ONLY audit.logs
+    ADD CONSTRAINT logs_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);
+`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string // rule IDs, objects, and columns or constraints of the findings
+	}{
+		{
+			name: "DropTable",
+			sql:  "DROP TABLE users;",
+			want: []string{"pg/drop-table users"},
+		},
+		{
+			name: "DropTableCascade",
+			sql:  "DROP TABLE users CASCADE;",
+			want: []string{
+				"pg/drop-table users",
+				"pg/drop-cascade users",
+				"pg/drop-cascade public.active_users",
+				"pg/drop-cascade public.active_user_posts",
+				"pg/drop-cascade public.posts posts_user_id_fkey",
+				"pg/drop-cascade audit.logs logs_user_id_fkey",
+			},
+		},
+		{
+			name: "DropViewCascade",
+			sql:  "DROP VIEW active_users CASCADE;",
+			want: []string{
+				"pg/drop-view active_users",
+				"pg/drop-cascade active_users",
+				"pg/drop-cascade public.active_user_posts",
+			},
+		},
+		{
+			name: "DropSchemaCascade",
+			sql:  "DROP SCHEMA audit CASCADE;",
+			want: []string{
+				"pg/drop-schema audit",
+				"pg/drop-cascade audit",
+				"pg/drop-cascade audit.logs",
+				"pg/drop-cascade public.recent_logs",
+			},
+		},
+		{
+			name: "DropTypeCascade",
+			sql:  "DROP TYPE public.mood CASCADE;",
+			want: []string{
+				"pg/drop-type public.mood",
+				"pg/drop-cascade public.mood",
+				"pg/drop-cascade public.users mood",
+				"pg/drop-cascade public.users moods",
+			},
+		},
+		{
+			name: "DropColumnCascade",
+			sql:  "ALTER TABLE users DROP COLUMN mood CASCADE;",
+			want: []string{"pg/drop-column users mood", "pg/drop-cascade users mood"},
+		},
+		{
+			name: "DependentsAlreadyDropped",
+			sql: `DROP VIEW active_user_posts;
+				ALTER TABLE posts DROP CONSTRAINT posts_user_id_fkey;
+				DROP TABLE users CASCADE;`,
+			want: []string{
+				"pg/drop-view active_user_posts",
+				"pg/drop-constraint posts posts_user_id_fkey",
+				"pg/drop-table users",
+				"pg/drop-cascade users",
+				"pg/drop-cascade public.active_users",
+				"pg/drop-cascade audit.logs logs_user_id_fkey",
+			},
+		},
+		{
+			name: "RenamedType",
+			sql: `ALTER TYPE mood RENAME TO feeling;
+				DROP TYPE feeling CASCADE;`,
+			want: []string{
+				"pg/rename-type mood",
+				"pg/drop-type feeling",
+				"pg/drop-cascade feeling",
+				"pg/drop-cascade public.users mood",
+				"pg/drop-cascade public.users moods",
+			},
+		},
+		{
+			name: "RenamedTable",
+			sql: `ALTER TABLE posts RENAME TO articles;
+				DROP TABLE articles CASCADE;`,
+			want: []string{
+				"pg/rename-table posts",
+				"pg/drop-table articles",
+				"pg/drop-cascade articles",
+				"pg/drop-cascade public.active_user_posts",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.RunPostgreSQLWithOptions(tt.sql, breaql.Options{Baseline: baseline})
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+				return strings.TrimSpace(strings.Join([]string{f.RuleID, f.Object, f.Column + f.Constraint}, " "))
+			}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("RunPostgreSQLWithOptions() findings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package breaql_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/ebi-yade/breaql"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRunPostgreSQL(t *testing.T) {
@@ -48,6 +51,19 @@ func TestRunPostgreSQL(t *testing.T) {
 			},
 			expectsErr: false,
 		},
+		{
+			name: "DropTableCascade",
+			sql: `CREATE TABLE users (id INT PRIMARY KEY);
+				CREATE TABLE posts (id INT PRIMARY KEY, user_id INT REFERENCES users (id));
+				CREATE VIEW active_users AS SELECT id FROM users;
+				DROP TABLE users CASCADE;`,
+			want: breaql.BreakingChanges{
+				Tables:      breaql.TableChanges{"users": {"DROP TABLE users CASCADE;"}, "posts": {"DROP TABLE users CASCADE;"}},
+				Views:       breaql.ViewChanges{"active_users": {"DROP TABLE users CASCADE;"}},
+				Constraints: breaql.ConstraintChanges{"posts.posts_user_id_fkey": {"DROP TABLE users CASCADE;"}},
+			},
+			expectsErr: false,
+		},
 		{
 			name: "TruncateTable",
 			sql:  "TRUNCATE TABLE test_schema.test_table;",
@@ -64,11 +80,52 @@ func TestRunPostgreSQL(t *testing.T) {
 			},
 			expectsErr: false,
 		},
+		{
+			name: "AlterViewRename",
+			sql:  "ALTER VIEW test_schema.test_view RENAME TO test_view_new;",
+			want: breaql.BreakingChanges{
+				Views: breaql.ViewChanges{"test_schema.test_view": {"ALTER VIEW test_schema.test_view RENAME TO test_view_new;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterMaterializedViewRename",
+			sql:  "ALTER MATERIALIZED VIEW test_mview RENAME TO test_mview_new;",
+			want: breaql.BreakingChanges{
+				Views: breaql.ViewChanges{"test_mview": {"ALTER MATERIALIZED VIEW test_mview RENAME TO test_mview_new;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterSequenceRename",
+			sql:  "ALTER SEQUENCE test_seq RENAME TO test_seq_new;",
+			want: breaql.BreakingChanges{
+				Sequences: breaql.SequenceChanges{"test_seq": {"ALTER SEQUENCE test_seq RENAME TO test_seq_new;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterTriggerRename",
+			sql:  "ALTER TRIGGER test_trigger ON test_schema.test_table RENAME TO test_trigger_new;",
+			want: breaql.BreakingChanges{
+				Triggers: breaql.TriggerChanges{"test_schema.test_table.test_trigger": {"ALTER TRIGGER test_trigger ON test_schema.test_table RENAME TO test_trigger_new;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterPolicyRename",
+			sql:  "ALTER POLICY test_policy ON test_table RENAME TO test_policy_new;",
+			want: breaql.BreakingChanges{
+				Policies: breaql.PolicyChanges{"test_table.test_policy": {"ALTER POLICY test_policy ON test_table RENAME TO test_policy_new;"}},
+			},
+			expectsErr: false,
+		},
 		{
 			name: "AlterTableDropColumn",
 			sql:  "ALTER TABLE test_schema.test_table DROP COLUMN column_name;",
 			want: breaql.BreakingChanges{
-				Tables: breaql.TableChanges{"test_schema.test_table": {"ALTER TABLE test_schema.test_table DROP COLUMN column_name;"}},
+				Tables:  breaql.TableChanges{"test_schema.test_table": {"ALTER TABLE test_schema.test_table DROP COLUMN column_name;"}},
+				Columns: breaql.ColumnChanges{"test_schema.test_table.column_name": {"ALTER TABLE test_schema.test_table DROP COLUMN column_name;"}},
 			},
 			expectsErr: false,
 		},
@@ -76,7 +133,8 @@ func TestRunPostgreSQL(t *testing.T) {
 			name: "AlterTableDropConstraint",
 			sql:  "ALTER TABLE test_schema.test_table DROP CONSTRAINT constraint_name;",
 			want: breaql.BreakingChanges{
-				Tables: breaql.TableChanges{"test_schema.test_table": {"ALTER TABLE test_schema.test_table DROP CONSTRAINT constraint_name;"}},
+				Tables:      breaql.TableChanges{"test_schema.test_table": {"ALTER TABLE test_schema.test_table DROP CONSTRAINT constraint_name;"}},
+				Constraints: breaql.ConstraintChanges{"test_schema.test_table.constraint_name": {"ALTER TABLE test_schema.test_table DROP CONSTRAINT constraint_name;"}},
 			},
 			expectsErr: false,
 		},
@@ -84,7 +142,125 @@ func TestRunPostgreSQL(t *testing.T) {
 			name: "AlterTableAlterColumn",
 			sql:  "ALTER TABLE test_schema.test_table ALTER COLUMN column_name TYPE VARCHAR(255);",
 			want: breaql.BreakingChanges{
-				Tables: breaql.TableChanges{"test_schema.test_table": {"ALTER TABLE test_schema.test_table ALTER COLUMN column_name TYPE VARCHAR(255);"}},
+				Tables:  breaql.TableChanges{"test_schema.test_table": {"ALTER TABLE test_schema.test_table ALTER COLUMN column_name TYPE VARCHAR(255);"}},
+				Columns: breaql.ColumnChanges{"test_schema.test_table.column_name": {"ALTER TABLE test_schema.test_table ALTER COLUMN column_name TYPE VARCHAR(255);"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropViews",
+			sql:  "DROP VIEW test_schema.test_view; DROP MATERIALIZED VIEW test_mv;",
+			want: breaql.BreakingChanges{
+				Views: breaql.ViewChanges{"test_schema.test_view": {"DROP VIEW test_schema.test_view;"}, "test_mv": {"DROP MATERIALIZED VIEW test_mv;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropForeignTable",
+			sql:  "DROP FOREIGN TABLE test_schema.test_remote;",
+			want: breaql.BreakingChanges{
+				Tables: breaql.TableChanges{"test_schema.test_remote": {"DROP FOREIGN TABLE test_schema.test_remote;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropSequence",
+			sql:  "DROP SEQUENCE IF EXISTS test_schema.test_seq;",
+			want: breaql.BreakingChanges{
+				Sequences: breaql.SequenceChanges{"test_schema.test_seq": {"DROP SEQUENCE IF EXISTS test_schema.test_seq;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropFunction",
+			sql:  "DROP FUNCTION test_schema.test_func(int, varchar(10), text[]), test_other;",
+			want: breaql.BreakingChanges{
+				Routines: breaql.RoutineChanges{
+					"test_schema.test_func(int4, varchar(10), text[])": {"DROP FUNCTION test_schema.test_func(int, varchar(10), text[]), test_other;"},
+					"test_other": {"DROP FUNCTION test_schema.test_func(int, varchar(10), text[]), test_other;"},
+				},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropProcedure",
+			sql:  "DROP PROCEDURE test_proc(IN a int, OUT b text);",
+			want: breaql.BreakingChanges{
+				Routines: breaql.RoutineChanges{"test_proc(int4)": {"DROP PROCEDURE test_proc(IN a int, OUT b text);"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropTypes",
+			sql:  "DROP TYPE test_schema.test_enum; DROP DOMAIN test_domain;",
+			want: breaql.BreakingChanges{
+				Types: breaql.TypeChanges{"test_schema.test_enum": {"DROP TYPE test_schema.test_enum;"}, "test_domain": {"DROP DOMAIN test_domain;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterTypes",
+			sql: `ALTER TYPE mood RENAME VALUE 'sad' TO 'unhappy';
+				ALTER TYPE mood ADD VALUE 'ok';
+				ALTER TYPE test_schema.address RENAME TO location;
+				ALTER TYPE location RENAME ATTRIBUTE city TO town;`,
+			want: breaql.BreakingChanges{
+				Types: breaql.TypeChanges{
+					"mood":                {"ALTER TYPE mood RENAME VALUE 'sad' TO 'unhappy';"},
+					"test_schema.address": {"ALTER TYPE test_schema.address RENAME TO location;"},
+					"location":            {"ALTER TYPE location RENAME ATTRIBUTE city TO town;"},
+				},
+				Columns: breaql.ColumnChanges{"location.city": {"ALTER TYPE location RENAME ATTRIBUTE city TO town;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "AlterDomains",
+			sql: `ALTER DOMAIN email ADD CONSTRAINT email_check CHECK (VALUE ~ '@');
+				ALTER DOMAIN email SET NOT NULL;
+				ALTER DOMAIN email DROP NOT NULL;
+				ALTER DOMAIN email SET DEFAULT '';
+				ALTER DOMAIN email RENAME CONSTRAINT email_check TO email_format;
+				ALTER DOMAIN email RENAME TO mail;`,
+			want: breaql.BreakingChanges{
+				Types: breaql.TypeChanges{
+					"email": {
+						"ALTER DOMAIN email ADD CONSTRAINT email_check CHECK (VALUE ~ '@');",
+						"ALTER DOMAIN email SET NOT NULL;",
+						"ALTER DOMAIN email RENAME CONSTRAINT email_check TO email_format;",
+						"ALTER DOMAIN email RENAME TO mail;",
+					},
+				},
+				Constraints: breaql.ConstraintChanges{
+					"email.email_check": {
+						"ALTER DOMAIN email ADD CONSTRAINT email_check CHECK (VALUE ~ '@');",
+						"ALTER DOMAIN email RENAME CONSTRAINT email_check TO email_format;",
+					},
+				},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropExtension",
+			sql:  "DROP EXTENSION pgcrypto;",
+			want: breaql.BreakingChanges{
+				Extensions: breaql.ExtensionChanges{"pgcrypto": {"DROP EXTENSION pgcrypto;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropTrigger",
+			sql:  "DROP TRIGGER test_trigger ON test_schema.test_table;",
+			want: breaql.BreakingChanges{
+				Triggers: breaql.TriggerChanges{"test_schema.test_table.test_trigger": {"DROP TRIGGER test_trigger ON test_schema.test_table;"}},
+			},
+			expectsErr: false,
+		},
+		{
+			name: "DropPolicy",
+			sql:  "DROP POLICY test_policy ON test_table;",
+			want: breaql.BreakingChanges{
+				Policies: breaql.PolicyChanges{"test_table.test_policy": {"DROP POLICY test_policy ON test_table;"}},
 			},
 			expectsErr: false,
 		},
@@ -106,6 +282,7 @@ func TestRunPostgreSQL(t *testing.T) {
 				Indexes:   breaql.IndexChanges{"test_index": {"DROP INDEX test_index;"}},
 				Schemas:   breaql.SchemaChanges{"test_schema": {"DROP SCHEMA test_schema;"}},
 				Databases: breaql.DatabaseChanges{"test_db": {"DROP DATABASE test_db;"}},
+				Columns:   breaql.ColumnChanges{"test_table.id": {"ALTER TABLE test_table DROP COLUMN id;"}},
 			},
 			expectsErr: false,
 		},
@@ -123,6 +300,10 @@ func TestRunPostgreSQL(t *testing.T) {
 						"ALTER TABLE test_table DROP COLUMN new_column;",
 					},
 				},
+				Columns: breaql.ColumnChanges{
+					"test_table.id":         {"ALTER TABLE test_table DROP COLUMN id;"},
+					"test_table.new_column": {"ALTER TABLE test_table DROP COLUMN new_column;"},
+				},
 			},
 			expectsErr: false,
 		},
@@ -139,6 +320,10 @@ func TestRunPostgreSQL(t *testing.T) {
 						"ALTER TABLE test_table DROP COLUMN new_column;",
 					},
 				},
+				Columns: breaql.ColumnChanges{
+					"test_table.id":         {"ALTER TABLE test_table DROP COLUMN id;"},
+					"test_table.new_column": {"ALTER TABLE test_table DROP COLUMN new_column;"},
+				},
 			},
 			expectsErr: false,
 		},
@@ -151,6 +336,7 @@ func TestRunPostgreSQL(t *testing.T) {
 
 	opts := []cmp.Option{
 		cmpopts.EquateEmpty(),
+		cmpopts.IgnoreFields(breaql.BreakingChanges{}, "Findings"),
 	}
 
 	for _, tt := range tests {
@@ -167,3 +353,102 @@ func TestRunPostgreSQL(t *testing.T) {
 		})
 	}
 }
+
+func TestRunPostgreSQL_Findings(t *testing.T) {
+	sql := `
+CREATE TABLE test_table (id INT PRIMARY KEY);
+  ALTER TABLE test_table RENAME COLUMN id TO test_id;
+DROP INDEX test_schema.test_index`
+
+	want := []breaql.Finding{
+		{
+			RuleID:     "pg/rename-column",
+			Severity:   breaql.SeverityError,
+			Category:   breaql.CategoryBreaking,
+			ObjectKind: breaql.ObjectKindTable,
+			Object:     "test_table",
+			Column:     "id",
+			NewName:    "test_id",
+			Statement:  "ALTER TABLE test_table RENAME COLUMN id TO test_id;",
+			Position:   breaql.Position{Offset: 49, Line: 3, Column: 3},
+			End:        breaql.Position{Offset: 99, Line: 3, Column: 53},
+			Message:    "column id of table test_table is renamed to test_id",
+		},
+		{
+			RuleID:     "pg/drop-index",
+			Severity:   breaql.SeverityWarning,
+			Category:   breaql.CategoryBreaking,
+			ObjectKind: breaql.ObjectKindIndex,
+			Object:     "test_schema.test_index",
+			Statement:  "DROP INDEX test_schema.test_index;",
+			Position:   breaql.Position{Offset: 101, Line: 4, Column: 1},
+			End:        breaql.Position{Offset: 134, Line: 4, Column: 34},
+			Message:    "index test_schema.test_index is dropped",
+		},
+	}
+
+	got, err := breaql.RunPostgreSQL(sql)
+	assert.NoError(t, err)
+	if diff := cmp.Diff(want, got.Findings); diff != "" {
+		t.Errorf("RunPostgreSQL() findings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunPostgreSQL_Renames(t *testing.T) {
+	got, err := breaql.RunPostgreSQL(`ALTER TABLE users RENAME TO members;
+ALTER FOREIGN TABLE remote_users RENAME TO remote_members;
+ALTER VIEW active_users RENAME TO active_members;
+ALTER MATERIALIZED VIEW user_stats RENAME TO member_stats;
+ALTER SEQUENCE users_id_seq RENAME TO members_id_seq;
+ALTER TRIGGER users_audit ON users RENAME TO members_audit;
+ALTER POLICY users_own ON users RENAME TO members_own;`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"pg/rename-table table table users is renamed to members",
+		"pg/rename-table table foreign table remote_users is renamed to remote_members",
+		"pg/rename-view view view active_users is renamed to active_members",
+		"pg/rename-view view materialized view user_stats is renamed to member_stats",
+		"pg/rename-sequence sequence sequence users_id_seq is renamed to members_id_seq",
+		"pg/rename-trigger trigger trigger users_audit on table users is renamed to members_audit",
+		"pg/rename-policy policy policy users_own on table users is renamed to members_own",
+	}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+		return f.RuleID + " " + string(f.ObjectKind) + " " + f.Message
+	}))
+	assert.Equal(t, breaql.TriggerChanges{"users.users_audit": {"ALTER TRIGGER users_audit ON users RENAME TO members_audit;"}}, got.Triggers)
+}
+
+func TestRunPostgreSQL_DuplicateObjects(t *testing.T) {
+	got, err := breaql.RunPostgreSQL("DROP TABLE a, a;")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pg/drop-table a"}, lo.Map(got.Findings, func(f breaql.Finding, _ int) string { return f.RuleID + " " + f.Object }))
+}
+
+func TestRunPostgreSQL_AddConstraints(t *testing.T) {
+	got, err := breaql.RunPostgreSQL(`ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email), ADD UNIQUE (nickname);
+ALTER TABLE users ADD CONSTRAINT users_age_check CHECK (age >= 0);
+ALTER TABLE users ADD CONSTRAINT users_score_check CHECK (score >= 0) NOT VALID;
+ALTER TABLE posts ADD CONSTRAINT posts_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;
+ALTER TABLE posts VALIDATE CONSTRAINT posts_user_id_fkey;
+ALTER TABLE users ADD CONSTRAINT users_code_key UNIQUE USING INDEX users_code_idx;
+CREATE UNIQUE INDEX CONCURRENTLY users_name_key ON users (name);
+CREATE INDEX users_name_idx ON users (name);`)
+	assert.NoError(t, err)
+
+	want := []string{
+		"pg/add-unique-constraint users.users_email_key",
+		"pg/add-unique-constraint users",
+		"pg/add-check-constraint users.users_age_check",
+		"pg/add-check-constraint users.users_score_check",
+		"pg/add-foreign-key posts.posts_user_id_fkey",
+		"pg/add-unique-constraint users.users_name_key",
+	}
+	if diff := cmp.Diff(want, lo.Map(got.Findings, func(f breaql.Finding, _ int) string {
+		return f.RuleID + " " + strings.Join(lo.Compact([]string{f.Object, f.Constraint}), ".")
+	})); diff != "" {
+		t.Errorf("RunPostgreSQL() findings mismatch (-want +got):\n%s", diff)
+	}
+	assert.Equal(t, "check constraint users_age_check is added to table users, which rejects the writes violating it and fails if an existing row violates it; add it NOT VALID and run VALIDATE CONSTRAINT separately",
+		got.Findings[2].Message)
+	assert.Equal(t, "foreign key posts_user_id_fkey is added to table posts, which rejects the writes referencing missing rows of table users; existing rows are not validated until VALIDATE CONSTRAINT, which should follow",
+		got.Findings[4].Message)
+}
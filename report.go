@@ -0,0 +1,59 @@
+package breaql
+
+// Report is the result of analyzing one or more SQL inputs with a driver.
+type Report struct {
+	Driver string // "mysql" or "pg"
+	Files  []FileReport
+}
+
+// FileReport is the result of analyzing a single SQL input.
+type FileReport struct {
+	Path    string // "-" for the standard input
+	Changes BreakingChanges
+}
+
+// Findings returns the findings of all the files in order, excluding the suppressed ones.
+func (r Report) Findings() []Finding {
+	var findings []Finding
+	for _, file := range r.Files {
+		findings = append(findings, file.Changes.Findings...)
+	}
+	return findings
+}
+
+// Sorted returns a copy of the report whose findings are arranged in the given order.
+func (r Report) Sorted(order Order) Report {
+	files := make([]FileReport, len(r.Files))
+	for i, file := range r.Files {
+		files[i] = FileReport{Path: file.Path, Changes: file.Changes.Sorted(order)}
+	}
+	r.Files = files
+	return r
+}
+
+// Summary holds the counts of the findings in a report.
+type Summary struct {
+	Files      int              `json:"files"`       // number of analyzed files
+	Findings   int              `json:"findings"`    // number of findings, excluding the suppressed ones
+	Suppressed int              `json:"suppressed"`  // number of suppressed findings
+	BySeverity map[Severity]int `json:"by_severity"` // number of findings per severity
+	ByRule     map[string]int   `json:"by_rule"`     // number of findings per rule ID
+}
+
+// Summary counts the findings in the report.
+func (r Report) Summary() Summary {
+	s := Summary{
+		Files:      len(r.Files),
+		BySeverity: make(map[Severity]int),
+		ByRule:     make(map[string]int),
+	}
+	for _, file := range r.Files {
+		s.Suppressed += len(file.Changes.Suppressed)
+	}
+	for _, f := range r.Findings() {
+		s.Findings++
+		s.BySeverity[f.Severity]++
+		s.ByRule[f.RuleID]++
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package breaql
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Reporter writes a report in a particular output format.
+type Reporter interface {
+	Report(w io.Writer, r Report) error
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(w io.Writer, r Report) error
+
+// Report calls f(w, r).
+func (f ReporterFunc) Report(w io.Writer, r Report) error {
+	return f(w, r)
+}
+
+var reporters = map[string]Reporter{
+	"sql":        ReporterFunc(WriteSQL),
+	"json":       ReporterFunc(WriteJSON),
+	"sarif":      ReporterFunc(WriteSARIF),
+	"github":     ReporterFunc(WriteGitHubActions),
+	"junit":      ReporterFunc(WriteJUnit),
+	"checkstyle": ReporterFunc(WriteCheckstyle),
+}
+
+// NewReporter returns the reporter for the given output format.
+func NewReporter(format string) (Reporter, error) {
+	reporter, ok := reporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	return reporter, nil
+}
+
+// Formats returns the names of the supported output formats.
+func Formats() []string {
+	formats := lo.Keys(reporters)
+	slices.Sort(formats)
+	return formats
+}
+
+// WriteSQL writes the report to w as SQL comments followed by the breaking statements.
+func WriteSQL(w io.Writer, r Report) error {
+	builder := strings.Builder{}
+	if len(r.Files) == 0 {
+		builder.WriteString("-- No destructive changes detected. --\n")
+	}
+	for _, file := range r.Files {
+		if len(r.Files) > 1 {
+			builder.WriteString("-- File: " + file.Path + "\n")
+		}
+		if file.Changes.Exist() {
+			builder.WriteString("-- Detected destructive changes:\n")
+			builder.WriteString(file.Changes.FormatSQL())
+		} else {
+			builder.WriteString("-- No destructive changes detected. --\n")
+		}
+		if risks := file.Changes.OperationalRisks(); len(risks) > 0 {
+			builder.WriteString("-- Operational risks:\n")
+			for _, f := range risks {
+				builder.WriteString("--   " + f.Message + " [" + f.RuleID + "]\n")
+				builder.WriteString("        " + f.Statement + "\n")
+			}
+		}
+		if len(file.Changes.Suppressed) > 0 {
+			builder.WriteString("-- Suppressed destructive changes:\n")
+			for _, f := range file.Changes.Suppressed {
+				builder.WriteString("--   " + f.Message + " [" + f.RuleID + "]")
+				if f.Suppression.Reason != "" {
+					builder.WriteString(": " + f.Suppression.Reason)
+				}
+				builder.WriteString("\n")
+			}
+		}
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// WriteGitHubActions writes the findings to w as GitHub Actions workflow commands,
+// which annotate the files in pull requests.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+func WriteGitHubActions(w io.Writer, r Report) error {
+	for _, file := range r.Files {
+		for _, f := range file.Changes.Findings {
+			var props []string
+			if file.Path != "-" {
+				props = append(props, "file="+escapeGitHubProperty(file.Path))
+			}
+			if file.Path != "-" && f.Position.Line > 0 {
+				props = append(props,
+					fmt.Sprintf("line=%d", f.Position.Line),
+					fmt.Sprintf("col=%d", f.Position.Column),
+					fmt.Sprintf("endLine=%d", f.End.Line),
+					fmt.Sprintf("endColumn=%d", f.End.Column),
+				)
+			}
+			props = append(props, "title="+escapeGitHubProperty(f.RuleID))
+			if _, err := fmt.Fprintf(w, "::%s %s::%s\n", gitHubLevel(f.Severity), strings.Join(props, ","), escapeGitHubData(f.Message)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func gitHubLevel(s Severity) string {
+	switch {
+	case s >= SeverityError:
+		return "error"
+	case s == SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+var (
+	gitHubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	gitHubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeGitHubData(s string) string {
+	return gitHubDataEscaper.Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return gitHubPropertyEscaper.Replace(s)
+}
@@ -0,0 +1,86 @@
+package breaql_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport(t *testing.T) breaql.Report {
+	t.Helper()
+	changes, err := breaql.RunMySQL("CREATE TABLE users (id INT);\nALTER TABLE users DROP COLUMN age, DROP INDEX idx_age;")
+	require.NoError(t, err)
+	return breaql.Report{
+		Driver: "mysql",
+		Files:  []breaql.FileReport{{Path: "db/migrations/001,users.sql", Changes: changes}},
+	}
+}
+
+func TestWriteGitHubActions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, breaql.WriteGitHubActions(buf, testReport(t)))
+
+	assert.Equal(t, "::error file=db/migrations/001%2Cusers.sql,line=2,col=1,endLine=2,endColumn=55,title=mysql/drop-column::column age is dropped from table users\n"+
+		"::warning file=db/migrations/001%2Cusers.sql,line=2,col=1,endLine=2,endColumn=55,title=mysql/drop-index::index idx_age is dropped from table users\n",
+		buf.String())
+}
+
+func TestWriteJUnit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, breaql.WriteJUnit(buf, testReport(t)))
+
+	assert.Contains(t, buf.String(), `<testsuites name="breaql" tests="2" failures="2" skipped="0">`)
+	assert.Contains(t, buf.String(), `<testcase name="mysql/drop-column users" classname="db/migrations/001,users.sql">`)
+	assert.Contains(t, buf.String(), `<failure message="column age is dropped from table users" type="mysql/drop-column">`)
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, breaql.WriteCheckstyle(buf, testReport(t)))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="8.0">
+  <file name="db/migrations/001,users.sql">
+    <error line="2" column="1" severity="error" message="column age is dropped from table users" source="breaql.mysql/drop-column"></error>
+    <error line="2" column="1" severity="warning" message="index idx_age is dropped from table users" source="breaql.mysql/drop-index"></error>
+  </file>
+</checkstyle>
+`, buf.String())
+}
+
+func TestNewReporter(t *testing.T) {
+	for _, format := range breaql.Formats() {
+		reporter, err := breaql.NewReporter(format)
+		require.NoError(t, err, format)
+		require.NoError(t, reporter.Report(&bytes.Buffer{}, testReport(t)), format)
+	}
+
+	_, err := breaql.NewReporter("yaml")
+	assert.Error(t, err)
+}
+
+func TestWriteSQL(t *testing.T) {
+	dropTable, err := breaql.RunMySQL("DROP TABLE users;")
+	require.NoError(t, err)
+	createTable, err := breaql.RunMySQL("CREATE TABLE users (id INT);")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, breaql.WriteSQL(buf, breaql.Report{
+		Driver: "mysql",
+		Files: []breaql.FileReport{
+			{Path: "001.sql", Changes: dropTable},
+			{Path: "002.sql", Changes: createTable},
+		},
+	}))
+
+	assert.Equal(t, "-- File: 001.sql\n"+
+		"-- Detected destructive changes:\n"+
+		"-- Table: users\n"+
+		"        DROP TABLE users;\n"+
+		"-- File: 002.sql\n"+
+		"-- No destructive changes detected. --\n", buf.String())
+}
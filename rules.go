@@ -0,0 +1,421 @@
+package breaql
+
+import (
+	"slices"
+)
+
+// Rule describes a kind of breaking change that breaql detects.
+type Rule struct {
+	ID       string   `json:"id"`
+	Severity Severity `json:"severity"` // default severity of the findings
+	Category Category `json:"category"`
+	Summary  string   `json:"summary"`
+	Help     string   `json:"help"` // why the change is risky and how to make it safely
+}
+
+// Category tells whether the findings of a rule break the applications or only put the database at risk while the statement runs.
+type Category string
+
+const (
+	// CategoryBreaking is the category of changes that lose data or break the applications using the database.
+	CategoryBreaking Category = "breaking"
+	// CategoryOperational is the category of statements that keep the data and the schema compatible,
+	// but may cause an outage while running, e.g. by blocking queries behind a lock.
+	// They are reported only if Options.OperationalRisks is set, and are not listed under the objects of BreakingChanges.
+	CategoryOperational Category = "operational"
+)
+
+var rules = []Rule{
+	// MySQL
+	{
+		ID: "mysql/drop-database", Severity: SeverityError, Summary: "Database is dropped",
+		Help: "Dropping a database deletes every table in it along with the data. Make sure no application connects to the database and take a backup beforehand.",
+	},
+	{
+		ID: "mysql/drop-table", Severity: SeverityError, Summary: "Table is dropped",
+		Help: "Dropping a table deletes its data and breaks every query that references it. Stop using the table in the application first, and consider renaming it for a while before dropping it.",
+	},
+	{
+		ID: "mysql/truncate-table", Severity: SeverityError, Summary: "Table is truncated",
+		Help: "Truncating a table deletes all of its rows and cannot be rolled back. Make sure the data is no longer needed or has been backed up.",
+	},
+	{
+		ID: "mysql/delete-all-rows", Severity: SeverityError, Summary: "DELETE removes every row",
+		Help: "A DELETE without a WHERE clause, or with an always-true one such as `WHERE 1 = 1`, removes every row of the table, which is rarely intended in a migration. Add the WHERE clause that picks the rows to delete, or use TRUNCATE TABLE if the data is really no longer needed.",
+	},
+	{
+		ID: "mysql/update-all-rows", Severity: SeverityError, Summary: "UPDATE overwrites every row",
+		Help: "An UPDATE without a WHERE clause, or with an always-true one, overwrites the columns of every row and loses their previous values. Add the WHERE clause that picks the rows to update, or back up the columns before overwriting them.",
+	},
+	{
+		ID: "mysql/rename-table", Severity: SeverityError, Summary: "Table is renamed",
+		Help: "Renaming a table breaks queries that still use the old name. Deploy application code that can work with both names before renaming it.",
+	},
+	{
+		ID: "mysql/drop-column", Severity: SeverityError, Summary: "Column is dropped",
+		Help: "Dropping a column deletes its data and breaks queries that reference it, including `INSERT` statements that set it. Stop using the column in the application first.",
+	},
+	{
+		ID: "mysql/rename-column", Severity: SeverityError, Summary: "Column is renamed",
+		Help: "Renaming a column breaks queries that still use the old name. Add the new column and migrate the application gradually instead.",
+	},
+	{
+		ID: "mysql/drop-index", Severity: SeverityWarning, Summary: "Index is dropped",
+		Help: "Dropping an index may slow down the queries that rely on it, and dropping a unique index stops enforcing uniqueness. Check query plans before dropping it.",
+	},
+	{
+		ID: "mysql/rename-index", Severity: SeverityWarning, Summary: "Index is renamed",
+		Help: "Renaming an index breaks index hints and migrations that refer to it by name.",
+	},
+	{
+		ID: "mysql/drop-foreign-key", Severity: SeverityWarning, Summary: "Foreign key is dropped",
+		Help: "Dropping a foreign key stops enforcing referential integrity, so orphaned rows may be written afterwards.",
+	},
+	{
+		ID: "mysql/drop-primary-key", Severity: SeverityError, Summary: "Primary key is dropped",
+		Help: "Dropping a primary key stops enforcing uniqueness of the rows and forces InnoDB to rebuild the table with a hidden clustered index.",
+	},
+	{
+		ID: "mysql/drop-check-constraint", Severity: SeverityWarning, Summary: "Check constraint is dropped",
+		Help: "Dropping a check constraint stops validating the rows, so invalid data may be written afterwards.",
+	},
+	{
+		ID: "mysql/modify-column", Severity: SeverityWarning, Summary: "Column definition is modified",
+		Help: "Redefining a column may narrow its type, change its charset or make it NOT NULL, which truncates data or rejects writes from the application. Compare the new definition with the current one, or pass the current schema as a baseline to classify the change.",
+	},
+	{
+		ID: "mysql/narrow-column-type", Severity: SeverityError, Summary: "Column type is narrowed",
+		Help: "The new type cannot hold every value of the old one, so the change fails or truncates data depending on the SQL mode, and the application may write values that no longer fit. Widen types only, e.g. `VARCHAR(50)` to `VARCHAR(100)` or `INT` to `BIGINT`.",
+	},
+	{
+		ID: "mysql/change-column-signedness", Severity: SeverityError, Summary: "Column signedness is changed",
+		Help: "Switching between `SIGNED` and `UNSIGNED` of the same size makes part of the existing range unrepresentable. Change an `UNSIGNED` column to a larger signed type instead.",
+	},
+	{
+		ID: "mysql/change-column-charset", Severity: SeverityError, Summary: "Column charset or collation is changed",
+		Help: "Converting a column to another character set may lose characters, and changing the collation changes comparisons, ordering and unique constraints. Only conversions such as `utf8mb3` to `utf8mb4` are lossless.",
+	},
+	{
+		ID: "mysql/column-set-not-null", Severity: SeverityError, Summary: "Column becomes NOT NULL",
+		Help: "Making a column NOT NULL fails on existing NULL values, or converts them to implicit defaults in non-strict mode, and rejects writers that leave the column unset. Backfill the column and update the writers first.",
+	},
+	{
+		ID: "mysql/add-not-null-column", Severity: SeverityError, Summary: "NOT NULL column is added without a default",
+		Help: "Old versions of the application do not set the new column, so their `INSERT` statements fail in strict mode, and the existing rows get the implicit default of the type such as 0 or ''. Add the column with a `DEFAULT`, or as nullable and make it NOT NULL once the writers set it.",
+	},
+	{
+		ID: "mysql/drop-column-default", Severity: SeverityWarning, Summary: "Default of a column is dropped",
+		Help: "Dropping the default of a NOT NULL column makes the `INSERT` statements leaving the column unset fail in strict mode. Update the writers to set the column first. Pass a baseline to report only the NOT NULL columns.",
+	},
+	{
+		ID: "mysql/add-unique-constraint", Severity: SeverityWarning, Summary: "Unique key is added",
+		Help: "A unique key rejects the writes of values that already exist, which the application may have written so far, and fails to be added if the existing rows have duplicates. Remove the duplicates and make the writers handle the duplicate key error first.",
+	},
+	{
+		ID: "mysql/add-check-constraint", Severity: SeverityWarning, Summary: "Check constraint is added",
+		Help: "An enforced check constraint rejects the writes violating it, and fails to be added if an existing row violates it. Fix the existing rows and the writers first, or add it `NOT ENFORCED` until then.",
+	},
+	{
+		ID: "mysql/add-foreign-key", Severity: SeverityWarning, Summary: "Foreign key is added",
+		Help: "A foreign key rejects the writes referencing missing rows and the deletes of referenced rows unless `ON DELETE` says otherwise, and fails to be added if an existing row references a missing one. Fix the existing rows and the order of the writes first.",
+	},
+	{
+		ID: "mysql/drop-partition", Severity: SeverityError, Summary: "Partition is dropped",
+		Help: "Dropping a partition deletes every row in it without firing `DELETE` triggers. Make sure the rows are no longer needed or have been archived, e.g. by exchanging the partition with a table first.",
+	},
+	{
+		ID: "mysql/truncate-partition", Severity: SeverityError, Summary: "Partition is truncated",
+		Help: "Truncating a partition deletes all of its rows and cannot be rolled back. Make sure the data is no longer needed or has been backed up.",
+	},
+	{
+		ID: "mysql/exchange-partition", Severity: SeverityError, Summary: "Partition is exchanged with a table",
+		Help: "Exchanging a partition swaps its rows with those of a standalone table, so the rows disappear from the partitioned table at once. Without `WITH VALIDATION`, rows that do not belong to the partition may also be moved into it.",
+	},
+	{
+		ID: "mysql/reorganize-partition", Severity: SeverityWarning, Summary: "Partitions are reorganized",
+		Help: "Reorganizing partitions copies their rows into new partitions, which locks the table for writes and may move rows to partitions with other names or boundaries. Check queries and maintenance jobs that refer to the partitions by name.",
+	},
+	{
+		ID: "mysql/coalesce-partition", Severity: SeverityWarning, Summary: "Partitions are coalesced",
+		Help: "Coalescing `HASH` or `KEY` partitions redistributes the rows of the whole table into fewer partitions, which takes a long time on large tables and drops the removed partitions.",
+	},
+	{
+		ID: "mysql/remove-partitioning", Severity: SeverityWarning, Summary: "Partitioning is removed",
+		Help: "Removing partitioning rebuilds the table with all of its rows, and drops the partitions that queries, maintenance jobs and retention policies may refer to by name.",
+	},
+	{
+		ID: "mysql/drop-view", Severity: SeverityError, Summary: "View is dropped",
+		Help: "Dropping a view breaks every query that reads from it. Stop using the view in the application first.",
+	},
+	{
+		ID: "mysql/replace-view", Severity: SeverityWarning, Summary: "View is redefined",
+		Help: "`CREATE OR REPLACE VIEW` may remove or rename columns of an existing view, which breaks queries that select them. Compare the new column list with the current one, or pass the current schema as a baseline to check it.",
+	},
+	{
+		ID: "mysql/change-view-columns", Severity: SeverityError, Summary: "Column is removed from a view",
+		Help: "Redefining a view without one of its columns breaks queries that select the column. Keep the column, e.g. as an alias of an expression, until the application stops using it.",
+	},
+	{
+		ID: "mysql/drop-sequence", Severity: SeverityError, Summary: "Sequence is dropped",
+		Help: "Dropping a sequence breaks `NEXTVAL()` calls and column defaults that use it. Stop using the sequence first.",
+	},
+	{
+		ID: "mysql/drop-trigger", Severity: SeverityWarning, Summary: "Trigger is dropped",
+		Help: "Dropping a trigger silently stops the logic it runs on writes, such as maintaining derived columns or audit tables. Move the logic to the application or another trigger first.",
+	},
+	{
+		ID: "mysql/drop-procedure", Severity: SeverityError, Summary: "Stored procedure is dropped",
+		Help: "Dropping a stored procedure breaks every `CALL` of it. Stop calling the procedure from the application and other routines first.",
+	},
+	{
+		ID: "mysql/drop-function", Severity: SeverityError, Summary: "Stored function is dropped",
+		Help: "Dropping a stored function breaks queries, views and generated columns that call it. Stop using the function first.",
+	},
+	{
+		ID: "mysql/drop-event", Severity: SeverityWarning, Summary: "Event is dropped",
+		Help: "Dropping an event stops the scheduled job it runs, such as purging or aggregating rows. Make sure the job is no longer needed or runs elsewhere.",
+	},
+
+	// PostgreSQL
+	{
+		ID: "pg/drop-database", Severity: SeverityError, Summary: "Database is dropped",
+		Help: "Dropping a database deletes every object in it along with the data. Make sure no application connects to the database and take a backup beforehand.",
+	},
+	{
+		ID: "pg/drop-schema", Severity: SeverityError, Summary: "Schema is dropped",
+		Help: "Dropping a schema breaks every query that references the objects in it. Make sure the schema is empty or no longer used.",
+	},
+	{
+		ID: "pg/drop-table", Severity: SeverityError, Summary: "Table is dropped",
+		Help: "Dropping a table deletes its data and breaks every query that references it. Stop using the table in the application first, and consider renaming it for a while before dropping it.",
+	},
+	{
+		ID: "pg/drop-index", Severity: SeverityWarning, Summary: "Index is dropped",
+		Help: "Dropping an index may slow down the queries that rely on it, and dropping a unique index stops enforcing uniqueness. Check query plans before dropping it.",
+	},
+	{
+		ID: "pg/drop-foreign-table", Severity: SeverityError, Summary: "Foreign table is dropped",
+		Help: "Dropping a foreign table breaks every query that reads from it, although the remote data is kept. Stop using the table in the application first.",
+	},
+	{
+		ID: "pg/drop-view", Severity: SeverityError, Summary: "View is dropped",
+		Help: "Dropping a view breaks every query that reads from it. Stop using the view in the application first.",
+	},
+	{
+		ID: "pg/drop-materialized-view", Severity: SeverityError, Summary: "Materialized view is dropped",
+		Help: "Dropping a materialized view deletes its data and breaks queries and `REFRESH MATERIALIZED VIEW` jobs that use it. Stop using the view first.",
+	},
+	{
+		ID: "pg/drop-sequence", Severity: SeverityError, Summary: "Sequence is dropped",
+		Help: "Dropping a sequence breaks `nextval()` calls and column defaults that use it, and its current value is lost. Stop using the sequence first.",
+	},
+	{
+		ID: "pg/drop-type", Severity: SeverityError, Summary: "Type is dropped",
+		Help: "Dropping a type breaks casts to it and functions that take it. Columns of the type must be dropped or converted beforehand.",
+	},
+	{
+		ID: "pg/drop-domain", Severity: SeverityError, Summary: "Domain is dropped",
+		Help: "Dropping a domain breaks casts to it and functions that take it, and the checks of the domain are no longer applied. Convert the columns of the domain beforehand.",
+	},
+	{
+		ID: "pg/drop-trigger", Severity: SeverityWarning, Summary: "Trigger is dropped",
+		Help: "Dropping a trigger silently stops the logic it runs on writes, such as maintaining derived columns or audit tables. Move the logic to the application or another trigger first.",
+	},
+	{
+		ID: "pg/drop-policy", Severity: SeverityError, Summary: "Row-level security policy is dropped",
+		Help: "Dropping a policy changes which rows are visible to the roles it applies to: rows allowed only by a permissive policy disappear, and rows hidden by a restrictive policy are exposed. Create the replacing policy first.",
+	},
+	{
+		ID: "pg/drop-function", Severity: SeverityError, Summary: "Function is dropped",
+		Help: "Dropping a function breaks queries, views, defaults and triggers that call it. Note that functions are identified by their argument types, so changing the arguments also drops the old function.",
+	},
+	{
+		ID: "pg/drop-procedure", Severity: SeverityError, Summary: "Procedure is dropped",
+		Help: "Dropping a procedure breaks every `CALL` of it. Stop calling the procedure from the application and other routines first.",
+	},
+	{
+		ID: "pg/drop-extension", Severity: SeverityError, Summary: "Extension is dropped",
+		Help: "Dropping an extension removes the types, functions and operators it provides, which breaks the queries and columns that use them.",
+	},
+	{
+		ID: "pg/drop-cascade", Severity: SeverityCritical, Summary: "Object is dropped with CASCADE",
+		Help: "CASCADE silently drops every object depending on the dropped one, such as views, foreign keys of other tables, columns of a dropped type and functions, far beyond the named object. Drop the dependent objects explicitly first and drop the object without CASCADE, so that a forgotten dependency fails the migration instead. Pass the current schema as a baseline to list the tables and views dropped along with it.",
+	},
+	{
+		ID: "pg/truncate-table", Severity: SeverityError, Summary: "Table is truncated",
+		Help: "Truncating a table deletes all of its rows. Make sure the data is no longer needed or has been backed up.",
+	},
+	{
+		ID: "pg/delete-all-rows", Severity: SeverityError, Summary: "DELETE removes every row",
+		Help: "A DELETE without a WHERE clause, or with an always-true one such as `WHERE true`, removes every row of the table, which is rarely intended in a migration. Add the WHERE clause that picks the rows to delete, or use TRUNCATE if the data is really no longer needed.",
+	},
+	{
+		ID: "pg/update-all-rows", Severity: SeverityError, Summary: "UPDATE overwrites every row",
+		Help: "An UPDATE without a WHERE clause, or with an always-true one, overwrites the columns of every row and loses their previous values. Add the WHERE clause that picks the rows to update, or back up the columns before overwriting them.",
+	},
+	{
+		ID: "pg/rename-table", Severity: SeverityError, Summary: "Table is renamed",
+		Help: "Renaming a table breaks queries that still use the old name. Deploy application code that can work with both names, e.g. through a view, before renaming it.",
+	},
+	{
+		ID: "pg/rename-column", Severity: SeverityError, Summary: "Column is renamed",
+		Help: "Renaming a column breaks queries that still use the old name. Add the new column and migrate the application gradually instead.",
+	},
+	{
+		ID: "pg/rename-constraint", Severity: SeverityWarning, Summary: "Constraint is renamed",
+		Help: "Renaming a constraint breaks code that matches the constraint name, e.g. in error handling or `ON CONFLICT ON CONSTRAINT` clauses.",
+	},
+	{
+		ID: "pg/rename-index", Severity: SeverityWarning, Summary: "Index is renamed",
+		Help: "Renaming an index breaks code and migrations that refer to it by name.",
+	},
+	{
+		ID: "pg/rename-view", Severity: SeverityError, Summary: "View is renamed",
+		Help: "Renaming a view or materialized view breaks queries that still use the old name. Create a view with the new name first, and drop the old one once the application has moved.",
+	},
+	{
+		ID: "pg/rename-sequence", Severity: SeverityWarning, Summary: "Sequence is renamed",
+		Help: "Column defaults follow the renamed sequence, but `nextval()`, `setval()` and `currval()` calls that name the sequence in the application or in functions break.",
+	},
+	{
+		ID: "pg/rename-trigger", Severity: SeverityInfo, Summary: "Trigger is renamed",
+		Help: "The trigger keeps firing, but migrations and scripts that refer to it by name, e.g. to disable or drop it, break.",
+	},
+	{
+		ID: "pg/rename-policy", Severity: SeverityInfo, Summary: "Row-level security policy is renamed",
+		Help: "The policy keeps applying, but migrations and scripts that refer to it by name, e.g. to alter or drop it, break.",
+	},
+	{
+		ID: "pg/drop-column", Severity: SeverityError, Summary: "Column is dropped",
+		Help: "Dropping a column deletes its data and breaks queries that reference it, including `INSERT` statements that set it. Stop using the column in the application first.",
+	},
+	{
+		ID: "pg/drop-constraint", Severity: SeverityWarning, Summary: "Constraint is dropped",
+		Help: "Dropping a constraint stops enforcing it, so invalid data may be written afterwards. Dropping a unique constraint also breaks `ON CONFLICT` clauses that rely on it.",
+	},
+	{
+		ID: "pg/add-not-null-column", Severity: SeverityError, Summary: "NOT NULL column is added without a default",
+		Help: "Adding a NOT NULL column without a default fails unless the table is empty, and old versions of the application do not set the new column, so their `INSERT` statements fail. Add the column with a `DEFAULT`, or as nullable and make it NOT NULL once it is backfilled and the writers set it.",
+	},
+	{
+		ID: "pg/column-set-not-null", Severity: SeverityError, Summary: "Column becomes NOT NULL",
+		Help: "Making a column NOT NULL fails on existing NULL values and rejects writers that leave the column unset or set it to NULL. Backfill the column and update the writers first.",
+	},
+	{
+		ID: "pg/drop-column-default", Severity: SeverityWarning, Summary: "Default of a column is dropped",
+		Help: "Dropping the default of a NOT NULL column makes the `INSERT` statements leaving the column unset fail. Update the writers to set the column first. Pass a baseline to report only the NOT NULL columns.",
+	},
+	{
+		ID: "pg/add-unique-constraint", Severity: SeverityWarning, Summary: "Unique constraint or index is added",
+		Help: "A unique or exclusion constraint, or a unique index, rejects the writes conflicting with existing rows, which the application may have written so far, and fails to be added if the existing rows have duplicates. A failed `CREATE UNIQUE INDEX CONCURRENTLY` leaves an invalid index behind. Remove the duplicates and make the writers handle the unique violation first.",
+	},
+	{
+		ID: "pg/add-check-constraint", Severity: SeverityWarning, Summary: "Check constraint is added",
+		Help: "A check constraint rejects the writes violating it, and fails to be added if an existing row violates it. Add it `NOT VALID`, which only checks the new writes, and run `VALIDATE CONSTRAINT` once the existing rows are fixed.",
+	},
+	{
+		ID: "pg/add-foreign-key", Severity: SeverityWarning, Summary: "Foreign key is added",
+		Help: "A foreign key rejects the writes referencing missing rows and the deletes of referenced rows unless `ON DELETE` says otherwise, and fails to be added if an existing row references a missing one. Add it `NOT VALID`, which only checks the new writes, and run `VALIDATE CONSTRAINT` once the existing rows are fixed.",
+	},
+	{
+		ID: "pg/rename-type", Severity: SeverityError, Summary: "Type is renamed",
+		Help: "Renaming a type, a domain or an attribute of a composite type breaks casts, function signatures and queries that still use the old name. Update the code that uses the type before renaming it.",
+	},
+	{
+		ID: "pg/rename-enum-value", Severity: SeverityError, Summary: "Enum value is renamed",
+		Help: "Renaming an enum value breaks writers that still send the old value and readers that compare with it. Add the new value, migrate the rows and the application, and stop using the old value instead.",
+	},
+	{
+		ID: "pg/add-domain-constraint", Severity: SeverityError, Summary: "Constraint is added to a domain",
+		Help: "A new domain constraint rejects writes that violate it in every column of the domain, and fails if an existing value violates it. Add it with `NOT VALID`, fix the existing data, and run `VALIDATE CONSTRAINT` afterwards.",
+	},
+	{
+		ID: "pg/domain-set-not-null", Severity: SeverityError, Summary: "Domain becomes NOT NULL",
+		Help: "Making a domain NOT NULL rejects writes of NULL to every column of the domain, and fails if an existing value is NULL. Backfill the columns and update the writers first.",
+	},
+	{
+		ID: "pg/alter-column-type", Severity: SeverityWarning, Summary: "Column type is changed",
+		Help: "Changing the type of a column may lose data or break the application reading it, and most changes rewrite the table under an ACCESS EXCLUSIVE lock. Pass the current schema as a baseline to classify the change.",
+	},
+	{
+		ID: "pg/narrow-column-type", Severity: SeverityError, Summary: "Column type is narrowed",
+		Help: "The new type cannot hold every value of the old one, so the change fails on existing data or silently rounds it, and the table is rewritten under an ACCESS EXCLUSIVE lock. Widen types only, e.g. `varchar(50)` to `varchar(100)` or `text`.",
+	},
+	{
+		ID: "pg/alter-column-type-rewrite", Severity: SeverityWarning, Summary: "Column type change rewrites the table",
+		Help: "The new type keeps the values, but the table and its indexes are rewritten while an ACCESS EXCLUSIVE lock blocks all reads and writes. On a large table, add a new column and backfill it in batches instead. Binary-coercible changes such as `varchar(n)` to `text` do not rewrite the table.",
+	},
+
+	// MySQL operational risks
+	{
+		ID: "mysql/ddl-copy", Severity: SeverityWarning, Category: CategoryOperational, Summary: "Table is copied with ALGORITHM=COPY",
+		Help: "InnoDB copies the whole table row by row while blocking writes, which takes long on a large table and doubles its disk usage meanwhile. Split the statement so that the other changes run in place, or use an online schema change tool such as gh-ost or pt-online-schema-change.",
+	},
+	{
+		ID: "mysql/ddl-inplace-lock", Severity: SeverityWarning, Category: CategoryOperational, Summary: "In-place ALTER TABLE blocks concurrent DML",
+		Help: "InnoDB changes the table in place but does not permit concurrent writes, or reads and writes, until it finishes, e.g. while building a full-text index. Run it in a maintenance window or use an online schema change tool.",
+	},
+	{
+		ID: "mysql/ddl-algorithm-conflict", Severity: SeverityError, Category: CategoryOperational, Summary: "ALGORITHM or LOCK clause is not supported",
+		Help: "The statement asks for a faster algorithm or a weaker lock than the operation supports on the target MySQL version, so MySQL rejects it. Split the statement, or drop the clause and accept the slower algorithm. Set the target version with `--mysql-version`.",
+	},
+	{
+		ID: "mysql/unbatched-dml", Severity: SeverityWarning, Category: CategoryOperational, Summary: "Rows are changed by a single unbatched statement",
+		Help: "An UPDATE, DELETE or INSERT ... SELECT that may change many rows holds their row locks until it commits, grows the undo log, and delays the replicas, which apply it only after it finishes on the source. Change the rows in batches, e.g. with LIMIT or ranges of the primary key, and commit each batch.",
+	},
+
+	// PostgreSQL operational risks
+	{
+		ID: "pg/lock-table-rewrite", Severity: SeverityWarning, Category: CategoryOperational, Summary: "Table is rewritten under a lock",
+		Help: "The statement copies the whole table and its indexes while an ACCESS EXCLUSIVE lock blocks all reads and writes, which takes long on a large table. Add the column without a volatile default and backfill it in batches, or use an online tool such as pg_repack.",
+	},
+	{
+		ID: "pg/lock-table-scan", Severity: SeverityWarning, Category: CategoryOperational, Summary: "Table is scanned under a lock",
+		Help: "The statement reads the whole table to validate a constraint or build an index while a lock blocks writes, or reads and writes. Add constraints with `NOT VALID` and run `VALIDATE CONSTRAINT` afterwards, which takes a weaker lock, and build unique indexes `CONCURRENTLY` before adding the constraint `USING INDEX`. `SET NOT NULL` skips the scan if a validated `CHECK (column IS NOT NULL)` constraint exists.",
+	},
+	{
+		ID: "pg/lock-non-concurrent-index", Severity: SeverityWarning, Category: CategoryOperational, Summary: "Index is built or dropped without CONCURRENTLY",
+		Help: "`CREATE INDEX` and `REINDEX` block writes to the table until the index is built, and `DROP INDEX` blocks all queries on the table. Use the `CONCURRENTLY` variants, which must run outside a transaction block.",
+	},
+	{
+		ID: "pg/unbatched-dml", Severity: SeverityWarning, Category: CategoryOperational, Summary: "Rows are changed by a single unbatched statement",
+		Help: "An UPDATE, DELETE or INSERT ... SELECT that may change many rows holds their row locks until it commits, blocking concurrent writes to them, and leaves as many dead tuples for VACUUM. Change the rows in batches, e.g. by ranges of the primary key or with a LIMITed subquery, and commit each batch.",
+	},
+}
+
+// Rules are breaking unless stated otherwise.
+func init() {
+	for i := range rules {
+		if rules[i].Category == "" {
+			rules[i].Category = CategoryBreaking
+		}
+	}
+}
+
+// Rules returns all the rules known to breaql.
+func Rules() []Rule {
+	return slices.Clone(rules)
+}
+
+// LookupRule returns the rule with the given ID.
+func LookupRule(id string) (Rule, bool) {
+	i := slices.IndexFunc(rules, func(r Rule) bool { return r.ID == id })
+	if i < 0 {
+		return Rule{}, false
+	}
+	return rules[i], true
+}
+
+func ruleCategory(id string) Category {
+	if rule, ok := LookupRule(id); ok {
+		return rule.Category
+	}
+	return CategoryBreaking
+}
+
+func ruleSeverity(id string) Severity {
+	if rule, ok := LookupRule(id); ok {
+		return rule.Severity
+	}
+	return SeverityWarning
+}
@@ -0,0 +1,141 @@
+package breaql
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+)
+
+// SARIF 2.1.0 documents, reduced to the properties breaql fills in.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool       sarifTool     `json:"tool"`
+		ColumnKind string        `json:"columnKind"`
+		Results    []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string             `json:"id"`
+		ShortDescription     sarifMessage       `json:"shortDescription"`
+		Help                 sarifMessage       `json:"help"`
+		DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	}
+	sarifConfiguration struct {
+		Level string `json:"level"`
+	}
+	sarifResult struct {
+		RuleID       string             `json:"ruleId"`
+		RuleIndex    int                `json:"ruleIndex"`
+		Level        string             `json:"level"`
+		Message      sarifMessage       `json:"message"`
+		Locations    []sarifLocation    `json:"locations"`
+		Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+	}
+	sarifSuppression struct {
+		Kind          string `json:"kind"`
+		Justification string `json:"justification,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine"`
+		EndColumn   int `json:"endColumn"`
+	}
+)
+
+// WriteSARIF writes the report to w as a SARIF 2.1.0 log, e.g. for GitHub code scanning.
+func WriteSARIF(w io.Writer, r Report) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "breaql",
+			InformationURI: "https://github.com/ebi-yade/breaql",
+			Rules:          make([]sarifRule, 0, len(rules)),
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	for _, rule := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Summary},
+			Help:                 sarifMessage{Text: rule.Help},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	for _, file := range r.Files {
+		// Suppressed findings are reported as well so that code scanning can show them as dismissed.
+		for _, f := range slices.Concat(file.Changes.Findings, file.Changes.Suppressed) {
+			result := sarifResult{
+				RuleID:    f.RuleID,
+				RuleIndex: slices.IndexFunc(rules, func(rule Rule) bool { return rule.ID == f.RuleID }),
+				Level:     sarifLevel(f.Severity),
+				Message:   sarifMessage{Text: f.Message},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file.Path)},
+					},
+				}},
+			}
+			// Findings without a position, e.g. on tables dropped from a schema snapshot, refer to the whole file.
+			if f.Position.Line > 0 {
+				result.Locations[0].PhysicalLocation.Region = &sarifRegion{
+					StartLine:   f.Position.Line,
+					StartColumn: f.Position.Column,
+					EndLine:     f.End.Line,
+					EndColumn:   f.End.Column,
+				}
+			}
+			if f.Suppression != nil {
+				result.Suppressions = []sarifSuppression{{Kind: "inSource", Justification: f.Suppression.Reason}}
+			}
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func sarifLevel(s Severity) string {
+	switch {
+	case s >= SeverityError:
+		return "error"
+	case s == SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
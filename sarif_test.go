@@ -0,0 +1,65 @@
+package breaql_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	changes, err := breaql.RunPostgreSQL("CREATE TABLE users (id INT);\nALTER TABLE users DROP COLUMN id;")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, breaql.WriteSARIF(buf, breaql.Report{
+		Driver: "pg",
+		Files:  []breaql.FileReport{{Path: "db/migrations/001.sql", Changes: changes}},
+	}))
+
+	var got struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID   string `json:"id"`
+						Help struct {
+							Text string `json:"text"`
+						} `json:"help"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region map[string]int `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+
+	assert.Equal(t, "2.1.0", got.Version)
+	require.Len(t, got.Runs, 1)
+	require.Len(t, got.Runs[0].Results, 1)
+
+	result := got.Runs[0].Results[0]
+	assert.Equal(t, "pg/drop-column", result.RuleID)
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, "pg/drop-column", got.Runs[0].Tool.Driver.Rules[result.RuleIndex].ID)
+	assert.NotEmpty(t, got.Runs[0].Tool.Driver.Rules[result.RuleIndex].Help.Text)
+	assert.Equal(t, "db/migrations/001.sql", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, map[string]int{"startLine": 2, "startColumn": 1, "endLine": 2, "endColumn": 33},
+		result.Locations[0].PhysicalLocation.Region)
+}
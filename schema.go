@@ -0,0 +1,313 @@
+package breaql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Schema is a catalog of the tables and views in a database, such as the one before a migration is applied.
+// Given as Options.Baseline, it lets the analysis tell whether a change to a column is actually breaking.
+// Load one with LoadMySQLSchema or LoadPostgreSQLSchema.
+type Schema struct {
+	driver string // "mysql" or "pg"
+	tables []*schemaTable
+	views  []*schemaView
+}
+
+type schemaTable struct {
+	name        string    // qualified if the schema is given
+	stmt        statement // the CREATE TABLE statement, used to locate the findings of Diff
+	charset     string    // default character set of the columns
+	collation   string    // default collation of the columns
+	columns     []*schemaColumn
+	indexes     []*schemaIndex
+	constraints []*schemaConstraint
+}
+
+type schemaView struct {
+	name      string   // qualified if the schema is given
+	columns   []string // nil if unknown, e.g. selected by a wildcard
+	relations []string // tables and views the view reads from; PostgreSQL only
+}
+
+type schemaColumn struct {
+	name    string
+	typ     columnType
+	notNull bool
+}
+
+// schemaIndex is an index other than the ones backing the constraints of PostgreSQL.
+type schemaIndex struct {
+	name    string   // unqualified
+	columns []string // column names, or "(expression)"
+	unique  bool
+}
+
+type constraintKind string
+
+const (
+	constraintPrimaryKey constraintKind = "PRIMARY KEY"
+	constraintUnique     constraintKind = "UNIQUE"
+	constraintForeignKey constraintKind = "FOREIGN KEY"
+	constraintCheck      constraintKind = "CHECK"
+	constraintExclusion  constraintKind = "EXCLUDE"
+)
+
+type schemaConstraint struct {
+	name       string // the name given, or the one generated by the database
+	kind       constraintKind
+	columns    []string
+	references string // the table referenced by a foreign key
+}
+
+// columnType is a column type normalized across drivers.
+type columnType struct {
+	name      string   // lower-case base name such as "varchar" or "bigint"
+	length    int      // length or precision; -1 if unspecified
+	scale     int      // scale or fractional seconds precision; -1 if unspecified
+	unsigned  bool     // MySQL only
+	charset   string   // explicitly specified character set
+	collation string   // explicitly specified collation
+	values    []string // values of ENUM and SET
+}
+
+func (t columnType) String() string {
+	name, array := strings.CutSuffix(t.name, "[]")
+	s := strings.ToUpper(name)
+	switch {
+	case name == "enum" || name == "set":
+		s += "(" + strings.Join(t.values, ",") + ")"
+	case t.length >= 0 && t.scale >= 0:
+		s += fmt.Sprintf("(%d,%d)", t.length, t.scale)
+	case t.length >= 0 && mysqlIntegerRank(name) == 0:
+		s += fmt.Sprintf("(%d)", t.length)
+	case t.length < 0 && t.scale >= 0:
+		s += fmt.Sprintf("(%d)", t.scale)
+	}
+	if t.unsigned {
+		s += " UNSIGNED"
+	}
+	if array {
+		s += "[]"
+	}
+	return s
+}
+
+// table returns the table with the given name.
+// A qualified name also matches a table in the catalog without the qualifier, and vice versa.
+func (s *Schema) table(name string) *schemaTable {
+	if s == nil {
+		return nil
+	}
+	if i := slices.IndexFunc(s.tables, func(t *schemaTable) bool { return t.name == name }); i >= 0 {
+		return s.tables[i]
+	}
+	if i := slices.IndexFunc(s.tables, func(t *schemaTable) bool { return unqualified(t.name) == unqualified(name) }); i >= 0 {
+		return s.tables[i]
+	}
+	return nil
+}
+
+func (s *Schema) putTable(t *schemaTable) {
+	s.dropTable(t.name)
+	s.tables = append(s.tables, t)
+}
+
+func (s *Schema) dropTable(name string) {
+	if t := s.table(name); t != nil {
+		s.tables = slices.DeleteFunc(s.tables, func(other *schemaTable) bool { return other == t })
+	}
+}
+
+// indexTable returns the table that has the index with the given unqualified name.
+func (s *Schema) indexTable(index string) *schemaTable {
+	if i := slices.IndexFunc(s.tables, func(t *schemaTable) bool { return t.index(index) != nil }); i >= 0 {
+		return s.tables[i]
+	}
+	return nil
+}
+
+// clone returns a deep copy of the schema so that a run can apply the statements to it.
+func (s *Schema) clone() *Schema {
+	if s == nil {
+		return &Schema{}
+	}
+	c := &Schema{driver: s.driver, tables: make([]*schemaTable, 0, len(s.tables)), views: make([]*schemaView, 0, len(s.views))}
+	for _, t := range s.tables {
+		c.tables = append(c.tables, t.clone())
+	}
+	for _, v := range s.views {
+		cv := *v
+		cv.relations = slices.Clone(v.relations)
+		c.views = append(c.views, &cv)
+	}
+	return c
+}
+
+// view returns the view with the given name, which is matched in the same way as table.
+func (s *Schema) view(name string) *schemaView {
+	if s == nil {
+		return nil
+	}
+	if i := slices.IndexFunc(s.views, func(v *schemaView) bool { return v.name == name }); i >= 0 {
+		return s.views[i]
+	}
+	if i := slices.IndexFunc(s.views, func(v *schemaView) bool { return unqualified(v.name) == unqualified(name) }); i >= 0 {
+		return s.views[i]
+	}
+	return nil
+}
+
+func (s *Schema) putView(v *schemaView) {
+	s.dropView(v.name)
+	s.views = append(s.views, v)
+}
+
+func (s *Schema) dropView(name string) {
+	if v := s.view(name); v != nil {
+		s.views = slices.DeleteFunc(s.views, func(other *schemaView) bool { return other == v })
+	}
+}
+
+// renameRelation updates the references to the renamed table or view.
+func (s *Schema) renameRelation(old, new string) {
+	for _, v := range s.views {
+		for i, r := range v.relations {
+			if sameRelation(r, old) {
+				v.relations[i] = new
+			}
+		}
+	}
+	for _, t := range s.tables {
+		for _, c := range t.constraints {
+			if c.references != "" && sameRelation(c.references, old) {
+				c.references = new
+			}
+		}
+	}
+}
+
+// renameType updates the columns of the renamed type, which are recorded without the schema.
+func (s *Schema) renameType(old, new string) {
+	for _, t := range s.tables {
+		for _, c := range t.columns {
+			if elem, array := strings.CutSuffix(c.typ.name, "[]"); elem == old {
+				c.typ.name = new
+				if array {
+					c.typ.name += "[]"
+				}
+			}
+		}
+	}
+}
+
+func (t *schemaTable) clone() *schemaTable {
+	c := *t
+	c.columns = make([]*schemaColumn, 0, len(t.columns))
+	for _, col := range t.columns {
+		cc := *col
+		c.columns = append(c.columns, &cc)
+	}
+	c.indexes = make([]*schemaIndex, 0, len(t.indexes))
+	for _, idx := range t.indexes {
+		ci := *idx
+		c.indexes = append(c.indexes, &ci)
+	}
+	c.constraints = make([]*schemaConstraint, 0, len(t.constraints))
+	for _, cons := range t.constraints {
+		cc := *cons
+		c.constraints = append(c.constraints, &cc)
+	}
+	return &c
+}
+
+// column returns the column with the given name, which is case-insensitive.
+func (t *schemaTable) column(name string) *schemaColumn {
+	if t == nil {
+		return nil
+	}
+	if i := slices.IndexFunc(t.columns, func(c *schemaColumn) bool { return strings.EqualFold(c.name, name) }); i >= 0 {
+		return t.columns[i]
+	}
+	return nil
+}
+
+// putColumn adds the column or replaces the one named old.
+func (t *schemaTable) putColumn(old string, c *schemaColumn) {
+	if i := slices.IndexFunc(t.columns, func(other *schemaColumn) bool { return strings.EqualFold(other.name, old) }); i >= 0 {
+		t.columns[i] = c
+		return
+	}
+	t.columns = append(t.columns, c)
+}
+
+func (t *schemaTable) dropColumn(name string) {
+	t.columns = slices.DeleteFunc(t.columns, func(c *schemaColumn) bool { return strings.EqualFold(c.name, name) })
+}
+
+// sameRelation returns whether the names refer to the same table or view.
+// A qualified name also matches an unqualified one, as in Schema.table.
+func sameRelation(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if strings.Contains(a, ".") && strings.Contains(b, ".") {
+		return false
+	}
+	return unqualified(a) == unqualified(b)
+}
+
+func unqualified(name string) string {
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+// index returns the index with the given name, which is case-insensitive.
+func (t *schemaTable) index(name string) *schemaIndex {
+	if i := slices.IndexFunc(t.indexes, func(idx *schemaIndex) bool { return strings.EqualFold(idx.name, name) }); i >= 0 {
+		return t.indexes[i]
+	}
+	return nil
+}
+
+func (t *schemaTable) putIndex(idx *schemaIndex) {
+	t.dropIndex(idx.name)
+	t.indexes = append(t.indexes, idx)
+}
+
+func (t *schemaTable) dropIndex(name string) {
+	t.indexes = slices.DeleteFunc(t.indexes, func(idx *schemaIndex) bool { return strings.EqualFold(idx.name, name) })
+}
+
+// constraint returns the constraint with the given name, which is case-insensitive.
+func (t *schemaTable) constraint(name string) *schemaConstraint {
+	if i := slices.IndexFunc(t.constraints, func(c *schemaConstraint) bool { return strings.EqualFold(c.name, name) }); i >= 0 {
+		return t.constraints[i]
+	}
+	return nil
+}
+
+func (t *schemaTable) putConstraint(c *schemaConstraint) {
+	t.dropConstraint(c.name)
+	t.constraints = append(t.constraints, c)
+}
+
+func (t *schemaTable) dropConstraint(name string) {
+	t.constraints = slices.DeleteFunc(t.constraints, func(c *schemaConstraint) bool { return strings.EqualFold(c.name, name) })
+}
+
+// primaryKey returns the primary key constraint of the table, if any.
+func (t *schemaTable) primaryKey() *schemaConstraint {
+	if i := slices.IndexFunc(t.constraints, func(c *schemaConstraint) bool { return c.kind == constraintPrimaryKey }); i >= 0 {
+		return t.constraints[i]
+	}
+	return nil
+}
+
+// constraintLabel returns e.g. "unique key uniq_email", or "unique key on (email)" if the constraint is unnamed.
+func constraintLabel(noun, name string, columns []string) string {
+	if name != "" || len(columns) == 0 {
+		return strings.TrimSpace(noun + " " + name)
+	}
+	return fmt.Sprintf("%s on (%s)", noun, strings.Join(columns, ", "))
+}
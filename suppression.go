@@ -0,0 +1,48 @@
+package breaql
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Suppression is an inline comment that accepts breaking changes in a statement, such as:
+//
+//	-- breaql:ignore
+//	-- breaql:ignore mysql/drop-column reason="the column has not been used since v2"
+//
+// The comment may be put on its own line before the statement, or at the end of its last line.
+type Suppression struct {
+	RuleIDs []string `json:"rule_ids,omitempty"` // rules to suppress; empty means all
+	Reason  string   `json:"reason,omitempty"`
+}
+
+const suppressionDirective = "breaql:ignore"
+
+var suppressionReason = regexp.MustCompile(`reason="([^"]*)"`)
+
+// parseSuppressions parses the breaql:ignore directives in the body of a comment.
+func parseSuppressions(body string) []Suppression {
+	var sups []Suppression
+	for _, line := range strings.Split(body, "\n") {
+		args, found := strings.CutPrefix(strings.TrimSpace(line), suppressionDirective)
+		if !found || (args != "" && !strings.HasPrefix(args, " ") && !strings.HasPrefix(args, "\t")) {
+			continue
+		}
+
+		var sup Suppression
+		if m := suppressionReason.FindStringSubmatchIndex(args); m != nil {
+			sup.Reason = args[m[2]:m[3]]
+			args = args[:m[0]] + args[m[1]:]
+		}
+		if ids := strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }); len(ids) > 0 {
+			sup.RuleIDs = ids
+		}
+		sups = append(sups, sup)
+	}
+	return sups
+}
+
+func (s Suppression) covers(ruleID string) bool {
+	return len(s.RuleIDs) == 0 || slices.Contains(s.RuleIDs, ruleID)
+}
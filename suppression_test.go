@@ -0,0 +1,54 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuppression(t *testing.T) {
+	sql := `-- breaql:ignore reason="reviewed in #123"
+DROP TABLE legacy;
+ALTER TABLE users DROP COLUMN age, DROP COLUMN name; -- breaql:ignore mysql/drop-column
+DROP TABLE users;
+/* breaql:ignore mysql/drop-index */
+DROP TABLE orders;`
+
+	ruleIDs := func(findings []breaql.Finding) []string {
+		return lo.Map(findings, func(f breaql.Finding, _ int) string { return f.RuleID + " " + f.Object })
+	}
+
+	got, err := breaql.RunMySQL(sql)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql/drop-table users", "mysql/drop-table orders"}, ruleIDs(got.Findings))
+	assert.Equal(t, []string{"mysql/drop-table legacy", "mysql/drop-column users", "mysql/drop-column users"}, ruleIDs(got.Suppressed))
+	assert.Equal(t, &breaql.Suppression{Reason: "reviewed in #123"}, got.Suppressed[0].Suppression)
+	assert.Equal(t, &breaql.Suppression{RuleIDs: []string{"mysql/drop-column"}}, got.Suppressed[1].Suppression)
+	assert.Equal(t, []string{"orders", "users"}, got.Tables.Tables())
+
+	got, err = breaql.RunMySQLWithOptions(sql, breaql.Options{RequireSuppressionReason: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql/drop-column users", "mysql/drop-column users", "mysql/drop-table users", "mysql/drop-table orders"}, ruleIDs(got.Findings))
+	assert.Equal(t, []string{"mysql/drop-table legacy"}, ruleIDs(got.Suppressed))
+	assert.Contains(t, got.Findings[0].Message, "without a reason")
+}
+
+func TestSuppression_PostgreSQL(t *testing.T) {
+	sql := `CREATE TABLE users (id INT);
+-- breaql:ignore pg/drop-column reason="moved to profiles"
+ALTER TABLE users DROP COLUMN age, DROP CONSTRAINT users_age_check;
+TRUNCATE users; -- breaql:ignore`
+
+	got, err := breaql.RunPostgreSQL(sql)
+	require.NoError(t, err)
+
+	require.Len(t, got.Findings, 1)
+	assert.Equal(t, "pg/drop-constraint", got.Findings[0].RuleID)
+	require.Len(t, got.Suppressed, 2)
+	assert.Equal(t, "pg/drop-column", got.Suppressed[0].RuleID)
+	assert.Equal(t, "moved to profiles", got.Suppressed[0].Suppression.Reason)
+	assert.Equal(t, "pg/truncate-table", got.Suppressed[1].RuleID)
+}
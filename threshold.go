@@ -0,0 +1,46 @@
+package breaql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Threshold decides which findings should fail a check, e.g. a CI job.
+// A finding matches if its severity is at least Severity or its rule is one of RuleIDs.
+type Threshold struct {
+	Severity Severity // zero means that no finding matches by severity
+	RuleIDs  []string
+}
+
+// ParseThreshold parses a comma-separated list of at most one severity and any number of rule IDs,
+// such as "error" or "warning,mysql/modify-column".
+func ParseThreshold(s string) (Threshold, error) {
+	var t Threshold
+	for _, token := range strings.Split(s, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if sev, err := ParseSeverity(token); err == nil {
+			if t.Severity != 0 {
+				return Threshold{}, fmt.Errorf("multiple severities in threshold: %q", s)
+			}
+			t.Severity = sev
+			continue
+		}
+		if _, ok := LookupRule(token); !ok {
+			return Threshold{}, fmt.Errorf("neither a severity nor a rule ID: %q", token)
+		}
+		t.RuleIDs = append(t.RuleIDs, token)
+	}
+	return t, nil
+}
+
+// Match returns whether the finding reaches the threshold.
+func (t Threshold) Match(f Finding) bool {
+	if t.Severity != 0 && f.Severity >= t.Severity {
+		return true
+	}
+	return slices.Contains(t.RuleIDs, f.RuleID)
+}
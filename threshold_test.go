@@ -0,0 +1,68 @@
+package breaql_test
+
+import (
+	"testing"
+
+	"github.com/ebi-yade/breaql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		want       breaql.Threshold
+		expectsErr bool
+	}{
+		{
+			name: "Severity",
+			s:    "error",
+			want: breaql.Threshold{Severity: breaql.SeverityError},
+		},
+		{
+			name: "RuleIDs",
+			s:    "mysql/drop-table, mysql/drop-column",
+			want: breaql.Threshold{RuleIDs: []string{"mysql/drop-table", "mysql/drop-column"}},
+		},
+		{
+			name: "SeverityAndRuleIDs",
+			s:    "critical,pg/drop-index",
+			want: breaql.Threshold{Severity: breaql.SeverityCritical, RuleIDs: []string{"pg/drop-index"}},
+		},
+		{
+			name:       "UnknownRule",
+			s:          "mysql/drop-everything",
+			expectsErr: true,
+		},
+		{
+			name:       "MultipleSeverities",
+			s:          "error,warning",
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := breaql.ParseThreshold(tt.s)
+			if tt.expectsErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestThreshold_Match(t *testing.T) {
+	dropIndex := breaql.Finding{RuleID: "mysql/drop-index", Severity: breaql.SeverityWarning}
+	dropTable := breaql.Finding{RuleID: "mysql/drop-table", Severity: breaql.SeverityError}
+
+	threshold := breaql.Threshold{Severity: breaql.SeverityError}
+	assert.False(t, threshold.Match(dropIndex))
+	assert.True(t, threshold.Match(dropTable))
+
+	threshold = breaql.Threshold{RuleIDs: []string{"mysql/drop-index"}}
+	assert.True(t, threshold.Match(dropIndex))
+	assert.False(t, threshold.Match(dropTable))
+}